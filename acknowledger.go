@@ -0,0 +1,99 @@
+package streams
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Acknowledger settles a message read from a stream explicitly, so handlers might defer the acknowledgement (e.g.
+// after an asynchronous flush) or request the redelivery of the message after a specific delay.
+//
+// Drivers supporting explicit acknowledgement inject an Acknowledger into both the handler context (see
+// AcknowledgerFromContext) and the Message. A message SHOULD be settled once; further calls return ErrMessageSettled.
+//
+// By default, drivers settle messages automatically using the handler result (auto-ack mode): a successful execution
+// acknowledges the message while a failed one is left to the driver redelivery policy. Use the WithManualAck
+// ReaderNodeOption to disable automatic settlement; handlers MUST settle every message then.
+type Acknowledger interface {
+	// Ack acknowledges the message was processed, so it will not be delivered again.
+	Ack(ctx context.Context) error
+	// Nack rejects the message, requesting its redelivery once the given delay has elapsed.
+	Nack(ctx context.Context, requeueAfter time.Duration) error
+	// Term rejects the message, requesting it to never be delivered again (e.g. poison messages).
+	Term(ctx context.Context) error
+}
+
+// ContextWithAcknowledger returns a copy of the given context holding the given Acknowledger.
+//
+// For Reader driver implementations only.
+func ContextWithAcknowledger(ctx context.Context, a Acknowledger) context.Context {
+	return context.WithValue(ctx, contextAcknowledger, a)
+}
+
+// AcknowledgerFromContext retrieves the Acknowledger of the message being processed, if the driver supports explicit
+// acknowledgement.
+func AcknowledgerFromContext(ctx context.Context) (Acknowledger, bool) {
+	a, ok := ctx.Value(contextAcknowledger).(Acknowledger)
+	return a, ok && a != nil
+}
+
+// OnceAcknowledger is an Acknowledger decorator settling a message at most once. Further calls return
+// ErrMessageSettled without reaching the decorated Acknowledger.
+//
+// For Reader driver implementations only.
+type OnceAcknowledger struct {
+	next    Acknowledger
+	mu      sync.Mutex
+	settled bool
+}
+
+var _ Acknowledger = &OnceAcknowledger{}
+
+// NewOnceAcknowledger allocates a new OnceAcknowledger decorating the given Acknowledger.
+func NewOnceAcknowledger(a Acknowledger) *OnceAcknowledger {
+	return &OnceAcknowledger{next: a}
+}
+
+// Ack acknowledges the message if it was not settled yet.
+func (a *OnceAcknowledger) Ack(ctx context.Context) error {
+	return a.settle(func() error {
+		return a.next.Ack(ctx)
+	})
+}
+
+// Nack rejects the message, requesting its redelivery, if it was not settled yet.
+func (a *OnceAcknowledger) Nack(ctx context.Context, requeueAfter time.Duration) error {
+	return a.settle(func() error {
+		return a.next.Nack(ctx, requeueAfter)
+	})
+}
+
+// Term rejects the message for good if it was not settled yet.
+func (a *OnceAcknowledger) Term(ctx context.Context) error {
+	return a.settle(func() error {
+		return a.next.Term(ctx)
+	})
+}
+
+// Settled reports whether the message was settled.
+func (a *OnceAcknowledger) Settled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.settled
+}
+
+// settle executes the given settlement operation if the message was not settled. The message remains unsettled if
+// the operation fails, so it might be retried.
+func (a *OnceAcknowledger) settle(f func() error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.settled {
+		return ErrMessageSettled
+	}
+	if err := f(); err != nil {
+		return err
+	}
+	a.settled = true
+	return nil
+}
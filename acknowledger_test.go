@@ -0,0 +1,65 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acknowledgerRecorder is an Acknowledger recording every settlement call, failing them if err is set.
+type acknowledgerRecorder struct {
+	calls []string
+	err   error
+}
+
+func (a *acknowledgerRecorder) Ack(_ context.Context) error {
+	a.calls = append(a.calls, "ack")
+	return a.err
+}
+
+func (a *acknowledgerRecorder) Nack(_ context.Context, requeueAfter time.Duration) error {
+	a.calls = append(a.calls, "nack "+strconv.Itoa(int(requeueAfter.Seconds())))
+	return a.err
+}
+
+func (a *acknowledgerRecorder) Term(_ context.Context) error {
+	a.calls = append(a.calls, "term")
+	return a.err
+}
+
+func TestAcknowledgerFromContext(t *testing.T) {
+	_, ok := AcknowledgerFromContext(context.Background())
+	assert.False(t, ok)
+
+	rec := &acknowledgerRecorder{}
+	ack, ok := AcknowledgerFromContext(ContextWithAcknowledger(context.Background(), rec))
+	require.True(t, ok)
+	assert.Equal(t, rec, ack)
+}
+
+func TestOnceAcknowledger(t *testing.T) {
+	ctx := context.Background()
+	rec := &acknowledgerRecorder{err: errors.New("generic ack error")}
+	ack := NewOnceAcknowledger(rec)
+	// failed settlements might be retried
+	assert.EqualError(t, ack.Nack(ctx, time.Minute), "generic ack error")
+	assert.False(t, ack.Settled())
+
+	rec.err = nil
+	require.NoError(t, ack.Nack(ctx, time.Minute))
+	assert.True(t, ack.Settled())
+	assert.ErrorIs(t, ack.Ack(ctx), ErrMessageSettled)
+	assert.ErrorIs(t, ack.Term(ctx), ErrMessageSettled)
+	assert.Equal(t, []string{"nack 60", "nack 60"}, rec.calls)
+
+	rec = &acknowledgerRecorder{}
+	ack = NewOnceAcknowledger(rec)
+	require.NoError(t, ack.Term(ctx))
+	assert.ErrorIs(t, ack.Ack(ctx), ErrMessageSettled)
+	assert.Equal(t, []string{"term"}, rec.calls)
+}
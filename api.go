@@ -57,22 +57,28 @@ func Start(ctx context.Context) {
 	DefaultHub.Start(ctx)
 }
 
+// Shutdown stops all daemons (e.g. stream-reading jobs) processes gracefully, bounded by the given context deadline.
+func Shutdown(ctx context.Context) error {
+	checkDefaultHubInstance()
+	return DefaultHub.Shutdown(ctx)
+}
+
 // Write inserts a message into a stream assigned to the message in the StreamRegistry in order to propagate the
 // data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
-func Write(ctx context.Context, message interface{}) error {
+// Uses given context to inject correlation and causation IDs, along with message headers.
+func Write(ctx context.Context, message interface{}, opts ...WriteOption) error {
 	checkDefaultHubInstance()
-	return DefaultHub.Write(ctx, message)
+	return DefaultHub.Write(ctx, message, opts...)
 }
 
 // WriteBatch inserts a set of messages into a stream assigned on the StreamRegistry in order to propagate the
 // data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
+// Uses given context to inject correlation and causation IDs, along with message headers.
 //
-// If an item from the batch fails, other items will fail too
-func WriteBatch(ctx context.Context, messages ...interface{}) (uint32, error) {
+// Returns the result of each message in the same order messages were given.
+func WriteBatch(ctx context.Context, messages ...interface{}) (BatchResult, error) {
 	checkDefaultHubInstance()
 	return DefaultHub.WriteBatch(ctx, messages...)
 }
@@ -80,19 +86,19 @@ func WriteBatch(ctx context.Context, messages ...interface{}) (uint32, error) {
 // WriteByMessageKey inserts a message into a stream using the custom message key from StreamRegistry in order to
 // propagate the data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
-func WriteByMessageKey(ctx context.Context, messageKey string, message interface{}) error {
+// Uses given context to inject correlation and causation IDs, along with message headers.
+func WriteByMessageKey(ctx context.Context, messageKey string, message interface{}, opts ...WriteOption) error {
 	checkDefaultHubInstance()
-	return DefaultHub.WriteByMessageKey(ctx, messageKey, message)
+	return DefaultHub.WriteByMessageKey(ctx, messageKey, message, opts...)
 }
 
 // WriteByMessageKeyBatch inserts a set of messages into a stream using the custom message key from StreamRegistry in order to
 // propagate the data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
+// Uses given context to inject correlation and causation IDs, along with message headers.
 //
-// If an item from the batch fails, other items will fail too
-func WriteByMessageKeyBatch(ctx context.Context, items WriteByMessageKeyBatchItems) (uint32, error) {
+// Returns the result of each message.
+func WriteByMessageKeyBatch(ctx context.Context, items WriteByMessageKeyBatchItems) (BatchResult, error) {
 	checkDefaultHubInstance()
 	return DefaultHub.WriteByMessageKeyBatch(ctx, items)
 }
@@ -112,8 +118,8 @@ func WriteRawMessage(ctx context.Context, message Message) error {
 // Uses given context to inject correlation and causation IDs.
 //
 // The whole batch will be passed to the underlying Writer driver implementation as every driver has its own way to
-// deal with batches
-func WriteRawMessageBatch(ctx context.Context, messages ...Message) (uint32, error) {
+// deal with batches. Returns the result of each message in the same order messages were given.
+func WriteRawMessageBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
 	checkDefaultHubInstance()
 	return DefaultHub.WriteRawMessageBatch(ctx, messages...)
 }
@@ -0,0 +1,101 @@
+package streams
+
+// BatchItemResult outcome of a single message from a batch write.
+type BatchItemResult struct {
+	// Message the transport message. Might be empty if the message could not be built (e.g. missing stream).
+	Message Message
+	// Err reason why the message was not written; nil if the message was written successfully.
+	Err error
+}
+
+// BatchResult outcome of each message from a batch write, in the same order messages were given. Thus, callers
+// might retry exactly the failed messages.
+type BatchResult []BatchItemResult
+
+// NewBatchResult allocates a BatchResult of the given messages, all of them marked as written.
+func NewBatchResult(messages []Message) BatchResult {
+	res := make(BatchResult, 0, len(messages))
+	for _, msg := range messages {
+		res = append(res, BatchItemResult{Message: msg})
+	}
+	return res
+}
+
+// FailAll marks every message of the batch as failed with the given error.
+func (r BatchResult) FailAll(err error) {
+	for i := range r {
+		r[i].Err = err
+	}
+}
+
+// Succeeded retrieves the number of messages written successfully.
+func (r BatchResult) Succeeded() uint32 {
+	var n uint32
+	for _, item := range r {
+		if item.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed retrieves the results of messages which were not written.
+func (r BatchResult) Failed() BatchResult {
+	failed := make(BatchResult, 0)
+	for _, item := range r {
+		if item.Err != nil {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// FailedMessages retrieves the messages which were not written, ready to be retried (e.g. using
+// Hub.WriteRawMessageBatch).
+func (r BatchResult) FailedMessages() []Message {
+	failed := make([]Message, 0)
+	for _, item := range r {
+		if item.Err != nil {
+			failed = append(failed, item.Message)
+		}
+	}
+	return failed
+}
+
+// Errors retrieves the error of each failed message, using the message ID as key.
+func (r BatchResult) Errors() map[string]error {
+	errs := map[string]error{}
+	for _, item := range r {
+		if item.Err != nil {
+			errs[item.Message.ID] = item.Err
+		}
+	}
+	return errs
+}
+
+// Err retrieves a MultiError with the error of each failed message; nil if every message was written.
+func (r BatchResult) Err() error {
+	errs := MultiError{}
+	for _, item := range r {
+		if item.Err != nil {
+			errs = append(errs, item.Err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// merge copies the given partial result into r, where indexes maps each position of the partial result into a
+// position of r. If the partial result is incomplete, unmapped messages get marked as failed with the given error
+// (or ErrBatchItemUnknown, if no error was given).
+func (r BatchResult) merge(indexes []int, partial BatchResult, err error) {
+	if err == nil {
+		err = ErrBatchItemUnknown
+	}
+	for pos, i := range indexes {
+		if pos < len(partial) {
+			r[i] = partial[pos]
+			continue
+		}
+		r[i].Err = err
+	}
+}
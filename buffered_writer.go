@@ -0,0 +1,383 @@
+package streams
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DeliveryCallback is called by a BufferedWriter once a message was written. The given error is nil if the message
+// was written successfully.
+//
+// Callbacks are called sequentially from the BufferedWriter background job, so they SHOULD NOT block.
+type DeliveryCallback func(message Message, err error)
+
+// DeliveryFuture is the outcome of a message written asynchronously, available once the message was written (or
+// failed to).
+type DeliveryFuture struct {
+	done chan struct{}
+	err  error
+}
+
+func newDeliveryFuture() *DeliveryFuture {
+	return &DeliveryFuture{
+		done: make(chan struct{}),
+	}
+}
+
+func (f *DeliveryFuture) resolve(err error) {
+	f.err = err
+	close(f.done)
+}
+
+// Done retrieves a channel which is closed once the message was written (or failed to).
+func (f *DeliveryFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err retrieves the delivery error of the message. Returns nil if the message has not been written yet; use Done
+// or Wait to wait for the delivery.
+func (f *DeliveryFuture) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until the message was written (or failed to) or the given context is done.
+func (f *DeliveryFuture) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BufferedWriter is a Writer decorator which holds messages in memory and writes them in batches through the
+// underlying Writer's WriteBatch once a size, byte or linger-time threshold is reached.
+//
+// Messages are written asynchronously, so Write returns as soon as the message was buffered. Use WriteAsync or the
+// WithDeliveryCallback option to get delivery confirmations. Batches are written one at a time, in the same order
+// messages were buffered.
+//
+// Once the maximum of pending batches is reached, writes filling up the buffer block until a batch gets written or
+// their context is done (backpressure). No lock is held while blocked, so Flush and Shutdown keep honouring their
+// contexts.
+//
+// A BufferedWriter runs a background job until Shutdown gets called, which writes every buffered message
+// (Hub.Shutdown does it if the BufferedWriter is the Hub Writer).
+type BufferedWriter struct {
+	next Writer
+	opts bufferedWriterOptions
+
+	mu          sync.Mutex
+	buffer      []bufferedMessage
+	bufferBytes int
+	// seq is incremented on every flush, so linger timers of already flushed buffers are ignored
+	seq    uint64
+	timer  *time.Timer
+	closed bool
+	// pending batches waiting to be written, in flush order
+	pending []bufferedBatch
+	// slots holds a token for every pending batch, bounding them to the maximum of pending batches; the batch being
+	// written is not pending
+	slots chan struct{}
+	// wake notifies the background job about new pending batches
+	wake chan struct{}
+	// done is closed once the BufferedWriter starts shutting down
+	done chan struct{}
+	// stopped is closed once every pending batch was written after closing the BufferedWriter
+	stopped chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+var (
+	_ Writer     = &BufferedWriter{}
+	_ Shutdowner = &BufferedWriter{}
+)
+
+type bufferedMessage struct {
+	message Message
+	future  *DeliveryFuture
+}
+
+type bufferedBatch struct {
+	messages []bufferedMessage
+	// flushed is closed once the batch was written; nil if no one is waiting for it
+	flushed chan struct{}
+	// slot reports whether the batch holds a pending batch token
+	slot bool
+}
+
+// NewBufferedWriter allocates a new BufferedWriter wrapping the given Writer and starts its background job.
+func NewBufferedWriter(w Writer, opts ...BufferedWriterOption) *BufferedWriter {
+	baseOpts := newBufferedWriterDefaults()
+	for _, o := range opts {
+		o.apply(&baseOpts)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &BufferedWriter{
+		next:    w,
+		opts:    baseOpts,
+		slots:   make(chan struct{}, baseOpts.maxPendingBatches),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	go b.run()
+	return b
+}
+
+// Write buffers the given message to be written in a later batch.
+//
+// Returns ErrWriterClosed if the BufferedWriter was shut down, or the context error if it is done while waiting for
+// a pending batch to be written (backpressure); the message is not buffered then.
+func (b *BufferedWriter) Write(ctx context.Context, message Message) error {
+	_, err := b.enqueue(ctx, message)
+	return err
+}
+
+// WriteBatch buffers the given set of messages to be written in later batches.
+//
+// Returned result reports whether each message was buffered, not written.
+func (b *BufferedWriter) WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
+	res := NewBatchResult(messages)
+	for i, msg := range messages {
+		_, res[i].Err = b.enqueue(ctx, msg)
+	}
+	return res, res.Err()
+}
+
+// WriteAsync buffers the given message to be written in a later batch, returning a DeliveryFuture to wait for the
+// message to be written.
+func (b *BufferedWriter) WriteAsync(ctx context.Context, message Message) *DeliveryFuture {
+	future, err := b.enqueue(ctx, message)
+	if err != nil {
+		future = newDeliveryFuture()
+		future.resolve(err)
+	}
+	return future
+}
+
+// Flush writes every buffered message, waiting for them (and any previously buffered message) to be written.
+func (b *BufferedWriter) Flush(ctx context.Context) error {
+	if err := b.acquireSlot(ctx); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		b.releaseSlot()
+		return ErrWriterClosed
+	}
+	flushed := make(chan struct{})
+	b.flushLocked(flushed)
+	b.mu.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown rejects further writes and writes every buffered message. If the given context is done before, pending
+// writes are canceled and their messages are reported as failed.
+//
+// Shuts down the underlying Writer afterwards if it implements Shutdowner.
+func (b *BufferedWriter) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		// the last batch is written regardless of the maximum of pending batches
+		b.pushLocked(bufferedBatch{messages: b.takeBufferLocked()})
+		close(b.done)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.stopped:
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+	b.cancel()
+	if shutdowner, ok := b.next.(Shutdowner); ok {
+		return shutdowner.Shutdown(ctx)
+	}
+	return nil
+}
+
+// enqueue appends the given message into the buffer, flushing it if a threshold was reached. Waits for a pending
+// batch token before buffering a message reaching a threshold.
+func (b *BufferedWriter) enqueue(ctx context.Context, message Message) (*DeliveryFuture, error) {
+	holdsSlot := false
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			if holdsSlot {
+				b.releaseSlot()
+			}
+			return nil, ErrWriterClosed
+		}
+		full := len(b.buffer)+1 >= b.opts.flushSize ||
+			(b.opts.flushBytes > 0 && b.bufferBytes+len(message.Data) >= b.opts.flushBytes)
+		if !full || holdsSlot {
+			future := newDeliveryFuture()
+			b.buffer = append(b.buffer, bufferedMessage{message: message, future: future})
+			b.bufferBytes += len(message.Data)
+			if full {
+				b.flushLocked(nil)
+			} else {
+				if holdsSlot {
+					// the buffer was flushed in the meantime
+					b.releaseSlot()
+				}
+				if len(b.buffer) == 1 {
+					b.startTimerLocked()
+				}
+			}
+			b.mu.Unlock()
+			return future, nil
+		}
+		b.mu.Unlock()
+
+		if err := b.acquireSlot(ctx); err != nil {
+			return nil, err
+		}
+		holdsSlot = true
+	}
+}
+
+// startTimerLocked schedules the flush of the buffer once the linger duration elapses. The mutex MUST be held.
+func (b *BufferedWriter) startTimerLocked() {
+	seq := b.seq
+	b.timer = time.AfterFunc(b.opts.flushInterval, func() {
+		if b.acquireSlot(b.ctx) != nil {
+			return
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.closed || b.seq != seq {
+			b.releaseSlot()
+			return
+		}
+		b.flushLocked(nil)
+	})
+}
+
+// acquireSlot waits for a pending batch token. Returns the context error if it is done before, or ErrWriterClosed if
+// the BufferedWriter starts shutting down.
+func (b *BufferedWriter) acquireSlot(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+		return ErrWriterClosed
+	}
+}
+
+func (b *BufferedWriter) releaseSlot() {
+	<-b.slots
+}
+
+// flushLocked queues the buffered messages to be written by the background job, using an already acquired pending
+// batch token.
+//
+// An empty batch is queued if flushed is not nil, so callers might wait for previous batches. The mutex MUST be held.
+func (b *BufferedWriter) flushLocked(flushed chan struct{}) {
+	messages := b.takeBufferLocked()
+	if len(messages) == 0 && flushed == nil {
+		b.releaseSlot()
+		return
+	}
+	b.pushLocked(bufferedBatch{
+		messages: messages,
+		flushed:  flushed,
+		slot:     true,
+	})
+}
+
+// takeBufferLocked empties the buffer, retrieving its messages. The mutex MUST be held.
+func (b *BufferedWriter) takeBufferLocked() []bufferedMessage {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	messages := b.buffer
+	b.buffer = nil
+	b.bufferBytes = 0
+	b.seq++
+	return messages
+}
+
+// pushLocked appends the given batch to the pending batches, notifying the background job. The mutex MUST be held.
+func (b *BufferedWriter) pushLocked(batch bufferedBatch) {
+	b.pending = append(b.pending, batch)
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run writes pending batches until the BufferedWriter gets closed and every pending batch was written.
+func (b *BufferedWriter) run() {
+	defer close(b.stopped)
+	for {
+		b.mu.Lock()
+		if len(b.pending) == 0 {
+			closed := b.closed
+			b.mu.Unlock()
+			if closed {
+				return
+			}
+			<-b.wake
+			continue
+		}
+		batch := b.pending[0]
+		b.pending = b.pending[1:]
+		b.mu.Unlock()
+		// the batch is no longer pending once it is being written
+		if batch.slot {
+			b.releaseSlot()
+		}
+
+		var res BatchResult
+		if len(batch.messages) > 0 {
+			res = b.deliver(batch.messages)
+		}
+		if batch.flushed != nil {
+			close(batch.flushed)
+		}
+		for i, msg := range batch.messages {
+			msg.future.resolve(res[i].Err)
+			if b.opts.deliveryCallback != nil {
+				b.opts.deliveryCallback(msg.message, res[i].Err)
+			}
+		}
+	}
+}
+
+// deliver writes the given messages through the underlying Writer, retrieving the result of each message.
+func (b *BufferedWriter) deliver(buffered []bufferedMessage) BatchResult {
+	messages := make([]Message, 0, len(buffered))
+	indexes := make([]int, 0, len(buffered))
+	for i, msg := range buffered {
+		messages = append(messages, msg.message)
+		indexes = append(indexes, i)
+	}
+	res := NewBatchResult(messages)
+	writeRes, err := b.next.WriteBatch(b.ctx, messages...)
+	res.merge(indexes, writeRes, err)
+	return res
+}
@@ -0,0 +1,111 @@
+package streams
+
+import "time"
+
+type bufferedWriterOptions struct {
+	flushSize         int
+	flushBytes        int
+	flushInterval     time.Duration
+	maxPendingBatches int
+	deliveryCallback  DeliveryCallback
+}
+
+// BufferedWriterOption enables configuration of a BufferedWriter.
+type BufferedWriterOption interface {
+	apply(*bufferedWriterOptions)
+}
+
+// defines the fallback options of a BufferedWriter instance.
+func newBufferedWriterDefaults() bufferedWriterOptions {
+	return bufferedWriterOptions{
+		flushSize:         10,
+		flushBytes:        256 * 1024,
+		flushInterval:     time.Millisecond * 50,
+		maxPendingBatches: 8,
+	}
+}
+
+type flushSizeOption struct {
+	Size int
+}
+
+func (o flushSizeOption) apply(opts *bufferedWriterOptions) {
+	if o.Size > 0 {
+		opts.flushSize = o.Size
+	}
+}
+
+// WithFlushSize sets the number of buffered messages which triggers a batch write of a BufferedWriter.
+//
+// Default is 10 messages (Amazon SQS, SNS and EventBridge batch limit).
+func WithFlushSize(n int) BufferedWriterOption {
+	return flushSizeOption{Size: n}
+}
+
+type flushBytesOption struct {
+	Bytes int
+}
+
+func (o flushBytesOption) apply(opts *bufferedWriterOptions) {
+	opts.flushBytes = o.Bytes
+}
+
+// WithFlushBytes sets the size in bytes of buffered message data which triggers a batch write of a BufferedWriter.
+// Use zero or a negative number to disable it.
+//
+// Default is 256 KiB (Amazon SQS, SNS and EventBridge payload limit).
+func WithFlushBytes(n int) BufferedWriterOption {
+	return flushBytesOption{Bytes: n}
+}
+
+type flushIntervalOption struct {
+	Interval time.Duration
+}
+
+func (o flushIntervalOption) apply(opts *bufferedWriterOptions) {
+	if o.Interval > 0 {
+		opts.flushInterval = o.Interval
+	}
+}
+
+// WithFlushInterval sets the maximum time (aka. linger time) a message might be buffered by a BufferedWriter
+// before being written.
+//
+// Default is 50 milliseconds.
+func WithFlushInterval(d time.Duration) BufferedWriterOption {
+	return flushIntervalOption{Interval: d}
+}
+
+type maxPendingBatchesOption struct {
+	Max int
+}
+
+func (o maxPendingBatchesOption) apply(opts *bufferedWriterOptions) {
+	if o.Max > 0 {
+		opts.maxPendingBatches = o.Max
+	}
+}
+
+// WithMaxPendingBatches sets the number of batches a BufferedWriter might hold while waiting to be written. Once
+// reached, writes block until a batch gets written or their context is done (backpressure).
+//
+// Default is 8 batches.
+func WithMaxPendingBatches(n int) BufferedWriterOption {
+	return maxPendingBatchesOption{Max: n}
+}
+
+type deliveryCallbackOption struct {
+	Callback DeliveryCallback
+}
+
+func (o deliveryCallbackOption) apply(opts *bufferedWriterOptions) {
+	opts.deliveryCallback = o.Callback
+}
+
+// WithDeliveryCallback sets the function called by a BufferedWriter once each message was written (or failed to).
+//
+// The callback runs within the BufferedWriter background job, so writing into the same BufferedWriter from it SHOULD
+// use a bounded context to avoid waiting on itself when the maximum of pending batches is reached.
+func WithDeliveryCallback(cb DeliveryCallback) BufferedWriterOption {
+	return deliveryCallbackOption{Callback: cb}
+}
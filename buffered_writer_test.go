@@ -0,0 +1,221 @@
+package streams_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder is a Writer recording every batch written, failing messages with an ID in failing.
+type batchRecorder struct {
+	mu       sync.Mutex
+	batches  [][]string
+	failing  map[string]bool
+	shutdown bool
+}
+
+func (r *batchRecorder) writer() writerShutdownHook {
+	return writerShutdownHook{
+		writerNoopHook: writerNoopHook{
+			onWriteBatch: func(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				res := streams.NewBatchResult(messages)
+				ids := make([]string, 0, len(messages))
+				for i, msg := range messages {
+					ids = append(ids, msg.ID)
+					if r.failing[msg.ID] {
+						res[i].Err = errors.New("generic writer error")
+					}
+				}
+				r.batches = append(r.batches, ids)
+				return res, res.Err()
+			},
+		},
+		onShutdown: func(_ context.Context) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.shutdown = true
+			return nil
+		},
+	}
+}
+
+func (r *batchRecorder) written() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string{}, r.batches...)
+}
+
+func TestBufferedWriter_FlushSize(t *testing.T) {
+	rec := &batchRecorder{}
+	w := streams.NewBufferedWriter(rec.writer(), streams.WithFlushSize(2),
+		streams.WithFlushInterval(time.Hour))
+	defer w.Shutdown(context.Background())
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.Write(context.Background(), streams.Message{ID: strconv.Itoa(i)}))
+	}
+	assert.Eventually(t, func() bool {
+		return len(rec.written()) == 2
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, [][]string{{"0", "1"}, {"2", "3"}}, rec.written())
+
+	// remaining messages are written on flush
+	require.NoError(t, w.Flush(context.Background()))
+	assert.Equal(t, [][]string{{"0", "1"}, {"2", "3"}, {"4"}}, rec.written())
+}
+
+func TestBufferedWriter_FlushBytes(t *testing.T) {
+	rec := &batchRecorder{}
+	w := streams.NewBufferedWriter(rec.writer(), streams.WithFlushBytes(4),
+		streams.WithFlushInterval(time.Hour))
+	defer w.Shutdown(context.Background())
+
+	res, err := w.WriteBatch(context.Background(),
+		streams.Message{ID: "1", Data: []byte("foo")},
+		streams.Message{ID: "2", Data: []byte("bar")},
+		streams.Message{ID: "3", Data: []byte("baz")})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), res.Succeeded())
+	assert.Eventually(t, func() bool {
+		return len(rec.written()) == 1
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, [][]string{{"1", "2"}}, rec.written())
+}
+
+func TestBufferedWriter_FlushInterval(t *testing.T) {
+	rec := &batchRecorder{}
+	w := streams.NewBufferedWriter(rec.writer(), streams.WithFlushInterval(time.Millisecond*20))
+	defer w.Shutdown(context.Background())
+
+	require.NoError(t, w.Write(context.Background(), streams.Message{ID: "1"}))
+	require.NoError(t, w.Write(context.Background(), streams.Message{ID: "2"}))
+	assert.Empty(t, rec.written())
+	assert.Eventually(t, func() bool {
+		return len(rec.written()) == 1
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, [][]string{{"1", "2"}}, rec.written())
+}
+
+func TestBufferedWriter_WriteAsync(t *testing.T) {
+	rec := &batchRecorder{failing: map[string]bool{"2": true}}
+	mu := sync.Mutex{}
+	delivered := map[string]error{}
+	w := streams.NewBufferedWriter(rec.writer(), streams.WithFlushSize(2),
+		streams.WithDeliveryCallback(func(message streams.Message, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered[message.ID] = err
+		}))
+	defer w.Shutdown(context.Background())
+
+	first := w.WriteAsync(context.Background(), streams.Message{ID: "1"})
+	assert.NoError(t, first.Err())
+	second := w.WriteAsync(context.Background(), streams.Message{ID: "2"})
+	assert.NoError(t, first.Wait(context.Background()))
+	assert.EqualError(t, second.Wait(context.Background()), "generic writer error")
+	<-second.Done()
+	assert.Error(t, second.Err())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, delivered, 2)
+	assert.NoError(t, delivered["1"])
+	assert.Error(t, delivered["2"])
+}
+
+func TestBufferedWriter_Shutdown(t *testing.T) {
+	rec := &batchRecorder{}
+	w := streams.NewBufferedWriter(rec.writer(), streams.WithFlushInterval(time.Hour))
+	hub := streams.NewHub(streams.WithWriter(w))
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "foo"}))
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "bar"}))
+	assert.Empty(t, rec.written())
+
+	// buffered messages are written on shutdown
+	require.NoError(t, hub.Shutdown(context.Background()))
+	require.Len(t, rec.written(), 1)
+	assert.Len(t, rec.written()[0], 2)
+	assert.True(t, rec.shutdown)
+
+	assert.ErrorIs(t, w.Write(context.Background(), streams.Message{}), streams.ErrWriterClosed)
+	assert.ErrorIs(t, w.WriteAsync(context.Background(), streams.Message{}).Err(), streams.ErrWriterClosed)
+	assert.ErrorIs(t, w.Flush(context.Background()), streams.ErrWriterClosed)
+	assert.NoError(t, w.Shutdown(context.Background()))
+}
+
+func TestBufferedWriter_Shutdown_Deadline(t *testing.T) {
+	release := make(chan struct{})
+	w := streams.NewBufferedWriter(writerNoopHook{
+		onWriteBatch: func(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			res := streams.NewBatchResult(messages)
+			res.FailAll(ctx.Err())
+			return res, res.Err()
+		},
+	}, streams.WithFlushSize(1))
+	defer close(release)
+
+	future := w.WriteAsync(context.Background(), streams.Message{ID: "1"})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.ErrorIs(t, w.Shutdown(ctx), context.DeadlineExceeded)
+	// in-flight writes are canceled
+	assert.ErrorIs(t, future.Wait(context.Background()), context.Canceled)
+}
+
+func TestBufferedWriter_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+	w := streams.NewBufferedWriter(writerNoopHook{
+		onWriteBatch: func(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			res := streams.NewBatchResult(messages)
+			res.FailAll(ctx.Err())
+			return res, res.Err()
+		},
+	}, streams.WithFlushSize(1), streams.WithMaxPendingBatches(1))
+	defer close(release)
+
+	// the first batch is being written, the second one fills up the pending batches
+	require.NoError(t, w.Write(context.Background(), streams.Message{ID: "1"}))
+	require.NoError(t, w.Write(context.Background(), streams.Message{ID: "2"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.ErrorIs(t, w.Write(ctx, streams.Message{ID: "3"}), context.DeadlineExceeded)
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- w.Write(context.Background(), streams.Message{ID: "4"})
+	}()
+
+	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancelShutdown()
+	start := time.Now()
+	assert.ErrorIs(t, w.Shutdown(ctxShutdown), context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	// writes blocked by backpressure are rejected once shutting down
+	select {
+	case err := <-blocked:
+		assert.ErrorIs(t, err, streams.ErrWriterClosed)
+	case <-time.After(time.Second):
+		t.Fatal("write still blocked after shutdown")
+	}
+}
@@ -0,0 +1,266 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState state of a CircuitBreaker.
+type CircuitState uint8
+
+const (
+	// CircuitClosed executions are allowed while failures are counted.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen executions are rejected until the open timeout elapses.
+	CircuitOpen
+	// CircuitHalfOpen a limited number of trial executions are allowed to decide whether the circuit closes again.
+	CircuitHalfOpen
+)
+
+// String retrieves the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitStateChangeHook is called every time a CircuitBreaker changes its state.
+type CircuitStateChangeHook func(name string, from, to CircuitState)
+
+// CircuitBreaker stops executions against an unhealthy dependency (e.g. a downstream API or a message broker) once
+// the ratio of failed executions reaches a threshold, allowing them again after a timeout if trial executions succeed.
+//
+// A single CircuitBreaker might be shared by several ReaderNode(s) and Writer(s) depending on the same resource.
+// Failures wrapped by Permanent are not counted as they are specific to a message.
+type CircuitBreaker struct {
+	name string
+	opts circuitBreakerOptions
+
+	mu          sync.Mutex
+	state       CircuitState
+	generation  uint64
+	changed     chan struct{}
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	trials      int
+	successes   int
+}
+
+// NewCircuitBreaker allocates a new closed CircuitBreaker. The given name is passed to the state change hook.
+func NewCircuitBreaker(name string, opts ...CircuitBreakerOption) *CircuitBreaker {
+	baseOpts := newCircuitBreakerDefaults()
+	for _, o := range opts {
+		o.apply(&baseOpts)
+	}
+	return &CircuitBreaker{
+		name:        name,
+		opts:        baseOpts,
+		changed:     make(chan struct{}),
+		windowStart: time.Now(),
+	}
+}
+
+// State retrieves the current state of the CircuitBreaker. An open circuit whose timeout elapsed is reported as
+// half-open, even if no execution was attempted since.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.openTimeoutElapsed() {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reserves an execution. If the execution is not allowed, it retrieves a channel closed on the next state
+// change and the duration until the circuit becomes half-open (if open).
+func (b *CircuitBreaker) allow() (generation uint64, ok bool, changed <-chan struct{}, retryIn time.Duration) {
+	b.mu.Lock()
+	var from CircuitState
+	transitioned := false
+	if b.state == CircuitOpen && b.openTimeoutElapsed() {
+		from, transitioned = b.state, true
+		b.setState(CircuitHalfOpen)
+	}
+	switch b.state {
+	case CircuitClosed:
+		ok = true
+	case CircuitOpen:
+		retryIn = b.opts.openTimeout - time.Since(b.openedAt)
+	case CircuitHalfOpen:
+		if b.trials < b.opts.halfOpenRequests {
+			b.trials++
+			ok = true
+		}
+	}
+	generation, changed = b.generation, b.changed
+	b.mu.Unlock()
+
+	if transitioned {
+		b.notify(from, CircuitHalfOpen)
+	}
+	return
+}
+
+// wait blocks until an execution is allowed. Returns ErrCircuitOpen if the given context is done before.
+func (b *CircuitBreaker) wait(ctx context.Context) (uint64, error) {
+	for {
+		generation, ok, changed, retryIn := b.allow()
+		if ok {
+			return generation, nil
+		}
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if retryIn > 0 {
+			timer = time.NewTimer(retryIn)
+			timeout = timer.C
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return 0, ErrCircuitOpen
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// done records the result of an execution allowed on the given generation. Results of executions allowed before
+// the last state change are ignored.
+func (b *CircuitBreaker) done(generation uint64, err error) {
+	failed := err != nil && !IsPermanent(err) && !errors.Is(err, ErrCircuitOpen)
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	from := b.state
+	switch b.state {
+	case CircuitClosed:
+		if time.Since(b.windowStart) >= b.opts.window {
+			b.windowStart, b.requests, b.failures = time.Now(), 0, 0
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.opts.minRequests &&
+			float64(b.failures)/float64(b.requests) >= b.opts.failureRatio {
+			b.setState(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			b.setState(CircuitOpen)
+			break
+		}
+		b.successes++
+		if b.successes >= b.opts.halfOpenRequests {
+			b.setState(CircuitClosed)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	if from != to {
+		b.notify(from, to)
+	}
+}
+
+// setState moves the CircuitBreaker into the given state, waking up every execution waiting for a state change.
+//
+// Requires the lock to be held.
+func (b *CircuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.generation++
+	b.windowStart, b.requests, b.failures = time.Now(), 0, 0
+	b.trials, b.successes = 0, 0
+	if state == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// openTimeoutElapsed reports whether an open CircuitBreaker is ready to allow trial executions.
+//
+// Requires the lock to be held.
+func (b *CircuitBreaker) openTimeoutElapsed() bool {
+	return time.Since(b.openedAt) >= b.opts.openTimeout
+}
+
+func (b *CircuitBreaker) notify(from, to CircuitState) {
+	if b.opts.stateChangeHook != nil {
+		b.opts.stateChangeHook(b.name, from, to)
+	}
+}
+
+// NewCircuitBreakerWriterBehaviour creates a WriterBehaviour failing fast with ErrCircuitOpen while the given
+// CircuitBreaker is open, so writes do not pile up against an unhealthy message broker.
+//
+// A batch counts as failed only if every message of the batch failed.
+func NewCircuitBreakerWriterBehaviour(b *CircuitBreaker) WriterBehaviour {
+	return func(_ *Hub, next Writer) Writer {
+		return circuitBreakerWriter{
+			next:    next,
+			breaker: b,
+		}
+	}
+}
+
+type circuitBreakerWriter struct {
+	next    Writer
+	breaker *CircuitBreaker
+}
+
+var _ Writer = circuitBreakerWriter{}
+
+func (w circuitBreakerWriter) Write(ctx context.Context, message Message) error {
+	generation, ok, _, _ := w.breaker.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+	err := w.next.Write(ctx, message)
+	w.breaker.done(generation, err)
+	return err
+}
+
+func (w circuitBreakerWriter) WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
+	generation, ok, _, _ := w.breaker.allow()
+	if !ok {
+		res := NewBatchResult(messages)
+		res.FailAll(ErrCircuitOpen)
+		return res, ErrCircuitOpen
+	}
+	res, err := w.next.WriteBatch(ctx, messages...)
+	w.breaker.done(generation, batchFailure(res, err))
+	return res, err
+}
+
+// batchFailure retrieves the given batch error only if the whole batch failed (every message failed or the Writer
+// reported no result of each message), so messages rejected on their own (e.g. too large) do not open the circuit.
+func batchFailure(res BatchResult, err error) error {
+	if err == nil || len(res) == 0 {
+		return err
+	}
+	for _, item := range res {
+		if item.Err == nil {
+			return nil
+		}
+	}
+	return err
+}
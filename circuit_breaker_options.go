@@ -0,0 +1,117 @@
+package streams
+
+import "time"
+
+type circuitBreakerOptions struct {
+	failureRatio     float64
+	minRequests      int
+	window           time.Duration
+	openTimeout      time.Duration
+	halfOpenRequests int
+	stateChangeHook  CircuitStateChangeHook
+}
+
+// CircuitBreakerOption enables configuration of a CircuitBreaker.
+type CircuitBreakerOption interface {
+	apply(*circuitBreakerOptions)
+}
+
+// defines the fallback options of a CircuitBreaker instance.
+func newCircuitBreakerDefaults() circuitBreakerOptions {
+	return circuitBreakerOptions{
+		failureRatio:     0.5,
+		minRequests:      10,
+		window:           time.Second * 10,
+		openTimeout:      time.Second * 15,
+		halfOpenRequests: 1,
+	}
+}
+
+type failureRatioOption struct {
+	Ratio       float64
+	MinRequests int
+}
+
+func (o failureRatioOption) apply(opts *circuitBreakerOptions) {
+	if o.Ratio > 0 {
+		opts.failureRatio = o.Ratio
+	}
+	if o.MinRequests > 0 {
+		opts.minRequests = o.MinRequests
+	}
+}
+
+// WithFailureRatio sets the ratio (0, 1] of failed executions which opens a CircuitBreaker, evaluated once the
+// counting window has at least minRequests executions.
+//
+// Default is a 0.5 ratio with 10 minimum executions.
+func WithFailureRatio(ratio float64, minRequests int) CircuitBreakerOption {
+	return failureRatioOption{Ratio: ratio, MinRequests: minRequests}
+}
+
+type failureWindowOption struct {
+	Window time.Duration
+}
+
+func (o failureWindowOption) apply(opts *circuitBreakerOptions) {
+	if o.Window > 0 {
+		opts.window = o.Window
+	}
+}
+
+// WithFailureWindow sets the duration of the window counting executions of a closed CircuitBreaker. Counts are reset
+// every time the window elapses.
+//
+// Default is 10 seconds.
+func WithFailureWindow(d time.Duration) CircuitBreakerOption {
+	return failureWindowOption{Window: d}
+}
+
+type openTimeoutOption struct {
+	Timeout time.Duration
+}
+
+func (o openTimeoutOption) apply(opts *circuitBreakerOptions) {
+	if o.Timeout > 0 {
+		opts.openTimeout = o.Timeout
+	}
+}
+
+// WithOpenTimeout sets the duration a CircuitBreaker stays open before allowing trial executions (half-open).
+//
+// Default is 15 seconds.
+func WithOpenTimeout(d time.Duration) CircuitBreakerOption {
+	return openTimeoutOption{Timeout: d}
+}
+
+type halfOpenRequestsOption struct {
+	Requests int
+}
+
+func (o halfOpenRequestsOption) apply(opts *circuitBreakerOptions) {
+	if o.Requests > 0 {
+		opts.halfOpenRequests = o.Requests
+	}
+}
+
+// WithHalfOpenRequests sets the number of trial executions allowed by a half-open CircuitBreaker. The circuit
+// closes once all of them succeeded and opens again as soon as one of them failed.
+//
+// Default is 1 execution.
+func WithHalfOpenRequests(n int) CircuitBreakerOption {
+	return halfOpenRequestsOption{Requests: n}
+}
+
+type stateChangeHookOption struct {
+	Hook CircuitStateChangeHook
+}
+
+func (o stateChangeHookOption) apply(opts *circuitBreakerOptions) {
+	opts.stateChangeHook = o.Hook
+}
+
+// WithStateChangeHook sets the function called every time a CircuitBreaker changes its state (e.g. logging, metrics
+// or alerting).
+func WithStateChangeHook(f CircuitStateChangeHook) CircuitBreakerOption {
+	return stateChangeHookOption{Hook: f}
+}
@@ -0,0 +1,127 @@
+package streams_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stateChangeRecorder records every state change of a CircuitBreaker.
+type stateChangeRecorder struct {
+	mu      sync.Mutex
+	changes []string
+}
+
+func (r *stateChangeRecorder) hook(name string, from, to streams.CircuitState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, name+": "+from.String()+" -> "+to.String())
+}
+
+func TestCircuitState_String(t *testing.T) {
+	assert.Equal(t, "closed", streams.CircuitClosed.String())
+	assert.Equal(t, "open", streams.CircuitOpen.String())
+	assert.Equal(t, "half-open", streams.CircuitHalfOpen.String())
+	assert.Equal(t, "unknown", streams.CircuitState(99).String())
+}
+
+func TestNewCircuitBreakerWriterBehaviour(t *testing.T) {
+	recorder := &stateChangeRecorder{}
+	breaker := streams.NewCircuitBreaker("broker",
+		streams.WithFailureRatio(0.5, 4),
+		streams.WithFailureWindow(time.Minute),
+		streams.WithOpenTimeout(time.Millisecond*20),
+		streams.WithHalfOpenRequests(2),
+		streams.WithStateChangeHook(recorder.hook))
+	broker := &streamRecorder{}
+	w := streams.NewCircuitBreakerWriterBehaviour(breaker)(nil, broker)
+	ctx := context.Background()
+
+	// failure ratio is evaluated after the minimum number of executions
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "1"}))
+	broker.err = errors.New("generic broker error")
+	for i := 0; i < 2; i++ {
+		assert.EqualError(t, w.Write(ctx, streams.Message{ID: "2"}), "generic broker error")
+	}
+	assert.Equal(t, streams.CircuitClosed, breaker.State())
+	assert.Error(t, w.Write(ctx, streams.Message{ID: "2"}))
+	assert.Equal(t, streams.CircuitOpen, breaker.State())
+
+	// open circuits fail fast
+	broker.err = nil
+	assert.ErrorIs(t, w.Write(ctx, streams.Message{ID: "3"}), streams.ErrCircuitOpen)
+	res, err := w.WriteBatch(ctx, streams.Message{ID: "4"}, streams.Message{ID: "5"})
+	assert.ErrorIs(t, err, streams.ErrCircuitOpen)
+	assert.Zero(t, res.Succeeded())
+	assert.Equal(t, []string{"1"}, broker.ids)
+	assert.Zero(t, broker.batchCalls)
+
+	// every trial execution must succeed to close the circuit
+	time.Sleep(time.Millisecond * 25)
+	assert.Equal(t, streams.CircuitHalfOpen, breaker.State())
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "6"}))
+	assert.Equal(t, streams.CircuitHalfOpen, breaker.State())
+	_, err = w.WriteBatch(ctx, streams.Message{ID: "7"})
+	require.NoError(t, err)
+	assert.Equal(t, streams.CircuitClosed, breaker.State())
+
+	// a failed trial execution opens the circuit again
+	broker.err = errors.New("generic broker error")
+	for i := 0; i < 4; i++ {
+		_ = w.Write(ctx, streams.Message{ID: "8"})
+	}
+	time.Sleep(time.Millisecond * 25)
+	assert.Error(t, w.Write(ctx, streams.Message{ID: "9"}))
+	assert.Equal(t, streams.CircuitOpen, breaker.State())
+
+	assert.Equal(t, []string{
+		"broker: closed -> open",
+		"broker: open -> half-open",
+		"broker: half-open -> closed",
+		"broker: closed -> open",
+		"broker: open -> half-open",
+		"broker: half-open -> open",
+	}, recorder.changes)
+}
+
+func TestNewCircuitBreakerWriterBehaviour_PartialBatch(t *testing.T) {
+	breaker := streams.NewCircuitBreaker("broker", streams.WithFailureRatio(0.25, 1))
+	w := streams.NewCircuitBreakerWriterBehaviour(breaker)(nil, writerNoopHook{
+		onWriteBatch: func(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+			res := streams.NewBatchResult(messages)
+			res[0].Err = errors.New("message too large")
+			return res, res.Err()
+		},
+	})
+
+	// a single rejected message does not count as a broker failure
+	for i := 0; i < 3; i++ {
+		res, err := w.WriteBatch(context.Background(), streams.Message{ID: "1"}, streams.Message{ID: "2"})
+		assert.Error(t, err)
+		assert.Equal(t, uint32(1), res.Succeeded())
+	}
+	assert.Equal(t, streams.CircuitClosed, breaker.State())
+
+	// a whole failed batch does
+	_, err := w.WriteBatch(context.Background(), streams.Message{ID: "1"})
+	assert.Error(t, err)
+	assert.Equal(t, streams.CircuitOpen, breaker.State())
+}
+
+func TestCircuitBreaker_Permanent(t *testing.T) {
+	breaker := streams.NewCircuitBreaker("broker", streams.WithFailureRatio(0.1, 1))
+	w := streams.NewCircuitBreakerWriterBehaviour(breaker)(nil, &streamRecorder{
+		err: streams.Permanent(errors.New("malformed message")),
+	})
+	for i := 0; i < 10; i++ {
+		assert.Error(t, w.Write(context.Background(), streams.Message{}))
+	}
+	// message-specific failures do not open the circuit
+	assert.Equal(t, streams.CircuitClosed, breaker.State())
+}
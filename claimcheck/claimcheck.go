@@ -0,0 +1,110 @@
+// Package claimcheck implements the claim-check pattern for streams: payloads of oversized messages are moved into a
+// BlobStore by the Writer, keeping only a reference in the message, and moved back into the message on the reader side.
+//
+// Useful for brokers with payload limits (e.g. Amazon SQS, SNS and EventBridge limit payloads to 256 KB).
+package claimcheck
+
+import (
+	"context"
+	"errors"
+
+	"github.com/neutrinocorp/streams"
+)
+
+// HeaderClaimCheck message header holding the BlobStore key of a message payload moved by the claim-check Writer.
+const HeaderClaimCheck = "claimcheck"
+
+// Config claim-check Writer configuration.
+type Config struct {
+	// Threshold message data size (in bytes) above which data is moved into the BlobStore.
+	Threshold int
+}
+
+// DefaultConfig default claim-check configuration.
+//
+// Threshold leaves room for message fields and base64-encoding overhead of drivers encoding messages as JSON
+// within a 256 KB payload limit.
+var DefaultConfig = Config{
+	Threshold: 128 * 1024,
+}
+
+func (c Config) withDefaults() Config {
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultConfig.Threshold
+	}
+	return c
+}
+
+// NewWriterBehaviour creates a streams.WriterBehaviour moving the data of outgoing messages above the configured
+// threshold into the given BlobStore.
+func NewWriterBehaviour(store BlobStore, cfg Config) streams.WriterBehaviour {
+	cfg = cfg.withDefaults()
+	return streams.NewWriterInterceptorBehaviour(func(ctx context.Context, message streams.Message) (streams.Message,
+		error) {
+		return checkIn(ctx, store, cfg, message)
+	})
+}
+
+// NewWriter wraps the given streams.Writer, moving the data of outgoing messages above the configured threshold into
+// the given BlobStore.
+func NewWriter(w streams.Writer, store BlobStore, cfg Config) streams.Writer {
+	return NewWriterBehaviour(store, cfg)(nil, w)
+}
+
+// checkIn moves the given message data into the store if it is above the threshold.
+func checkIn(ctx context.Context, store BlobStore, cfg Config, message streams.Message) (streams.Message, error) {
+	if len(message.Data) <= cfg.Threshold {
+		return message, nil
+	}
+	key := newBlobKey(message)
+	if err := store.Put(ctx, key, message.Data); err != nil {
+		return message, err
+	}
+	headers := make(map[string]string, len(message.Headers)+1)
+	for k, v := range message.Headers {
+		headers[k] = v
+	}
+	headers[HeaderClaimCheck] = key
+	message.Headers = headers
+	message.Data = nil
+	return message, nil
+}
+
+// newBlobKey builds the BlobStore key of a message payload.
+func newBlobKey(message streams.Message) string {
+	return message.Stream + "/" + message.ID
+}
+
+// NewReaderBehaviour creates a streams.ReaderBehaviour moving the data of incoming messages from the given BlobStore
+// back into the messages.
+//
+// Register it using streams.WithReaderBehaviours, so it gets executed before the unmarshalling behaviour. Messages
+// whose data was not found are not retried (streams.Permanent).
+func NewReaderBehaviour(store BlobStore) streams.ReaderBehaviour {
+	return func(_ *streams.ReaderNode, _ *streams.Hub, next streams.ReaderHandleFunc) streams.ReaderHandleFunc {
+		return func(ctx context.Context, message streams.Message) error {
+			key, ok := message.Headers[HeaderClaimCheck]
+			if !ok {
+				return next(ctx, message)
+			}
+			data, err := store.Get(ctx, key)
+			if errors.Is(err, ErrBlobNotFound) {
+				return streams.Permanent(err)
+			} else if err != nil {
+				return err
+			}
+			var headers map[string]string
+			for k, v := range message.Headers {
+				if k == HeaderClaimCheck {
+					continue
+				} else if headers == nil {
+					headers = make(map[string]string, len(message.Headers)-1)
+				}
+				headers[k] = v
+			}
+			message.Headers = headers
+			message.Data = data
+			return next(ctx, message)
+		}
+	}
+}
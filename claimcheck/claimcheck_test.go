@@ -0,0 +1,154 @@
+package claimcheck_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/claimcheck"
+	"github.com/neutrinocorp/streams/driver/shmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory BlobStore.
+type memoryStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+	err   error
+}
+
+func (s *memoryStore) Put(_ context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.blobs[key] = data
+	return nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, claimcheck.ErrBlobNotFound
+	}
+	return data, nil
+}
+
+type writerFunc func(context.Context, streams.Message) error
+
+func (f writerFunc) Write(ctx context.Context, message streams.Message) error {
+	return f(ctx, message)
+}
+
+func (f writerFunc) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	for i, msg := range messages {
+		res[i].Err = f(ctx, msg)
+	}
+	return res, res.Err()
+}
+
+func TestNewWriter(t *testing.T) {
+	store := &memoryStore{blobs: map[string][]byte{}}
+	var written []streams.Message
+	w := claimcheck.NewWriter(writerFunc(func(_ context.Context, message streams.Message) error {
+		written = append(written, message)
+		return nil
+	}), store, claimcheck.Config{Threshold: 4})
+
+	headers := map[string]string{"tenantid": "foo"}
+	ctx := context.Background()
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "1", Stream: "foo-stream", Data: []byte("foo"),
+		Headers: headers}))
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "2", Stream: "foo-stream", Data: []byte("foobar"),
+		Headers: headers}))
+
+	require.Len(t, written, 2)
+	assert.Equal(t, []byte("foo"), written[0].Data)
+	assert.Equal(t, headers, written[0].Headers)
+	assert.Nil(t, written[1].Data)
+	assert.Equal(t, map[string]string{"tenantid": "foo", claimcheck.HeaderClaimCheck: "foo-stream/2"},
+		written[1].Headers)
+	assert.Equal(t, []byte("foobar"), store.blobs["foo-stream/2"])
+	// caller headers are not modified
+	assert.Len(t, headers, 1)
+
+	store.err = errors.New("generic store error")
+	res, err := w.WriteBatch(ctx, streams.Message{ID: "3", Stream: "foo-stream", Data: []byte("foobar")},
+		streams.Message{ID: "4", Stream: "foo-stream", Data: []byte("foo")})
+	assert.Error(t, err)
+	assert.EqualError(t, res[0].Err, "generic store error")
+	assert.NoError(t, res[1].Err)
+	assert.Len(t, written, 3)
+}
+
+func TestNewReaderBehaviour(t *testing.T) {
+	store := &memoryStore{blobs: map[string][]byte{"foo-stream/1": []byte("foobar")}}
+	var received []streams.Message
+	handler := claimcheck.NewReaderBehaviour(store)(nil, nil, func(_ context.Context, message streams.Message) error {
+		received = append(received, message)
+		return nil
+	})
+
+	ctx := context.Background()
+	require.NoError(t, handler(ctx, streams.Message{ID: "0", Data: []byte("foo")}))
+	require.NoError(t, handler(ctx, streams.Message{ID: "1", Headers: map[string]string{
+		claimcheck.HeaderClaimCheck: "foo-stream/1",
+	}}))
+	err := handler(ctx, streams.Message{ID: "2", Headers: map[string]string{
+		claimcheck.HeaderClaimCheck: "foo-stream/2",
+		"tenantid":                  "foo",
+	}})
+	assert.ErrorIs(t, err, claimcheck.ErrBlobNotFound)
+	assert.True(t, streams.IsPermanent(err))
+
+	require.Len(t, received, 2)
+	assert.Equal(t, []byte("foo"), received[0].Data)
+	assert.Equal(t, []byte("foobar"), received[1].Data)
+	assert.Nil(t, received[1].Headers)
+}
+
+type fooMessage struct {
+	Foo []byte `json:"foo"`
+}
+
+func TestClaimCheck_Hub(t *testing.T) {
+	store, err := claimcheck.NewFileSystemStore(t.TempDir())
+	require.NoError(t, err)
+	bus := shmemory.NewBus(0)
+	hub := streams.NewHub(
+		streams.WithWriter(shmemory.NewWriter(bus)),
+		streams.WithReader(shmemory.NewReader(bus)),
+		streams.WithWriterBehaviours(claimcheck.NewWriterBehaviour(store, claimcheck.Config{Threshold: 64})),
+		streams.WithReaderBehaviours(claimcheck.NewReaderBehaviour(store)))
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+
+	received := make(chan fooMessage, 1)
+	require.NoError(t, hub.Read(fooMessage{}, streams.WithHandlerFunc(func(_ context.Context,
+		message streams.Message) error {
+		received <- message.DecodedData.(fooMessage)
+		return nil
+	})))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub.Start(ctx)
+
+	payload := fooMessage{Foo: bytes.Repeat([]byte("foo"), 64)}
+	require.NoError(t, hub.Write(context.Background(), payload))
+	select {
+	case msg := <-received:
+		assert.Equal(t, payload, msg)
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+}
@@ -0,0 +1,66 @@
+package claimcheck
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystemStore is the local file system implementation of BlobStore, storing each blob as a file within a
+// directory.
+type FileSystemStore struct {
+	dir string
+}
+
+var _ BlobStore = FileSystemStore{}
+
+// NewFileSystemStore allocates a new FileSystemStore, creating the given directory if it does not exist.
+func NewFileSystemStore(dir string) (FileSystemStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return FileSystemStore{}, err
+	}
+	return FileSystemStore{dir: dir}, nil
+}
+
+// Put writes the given data into the file of the given key. The file is replaced atomically, so concurrent readers
+// never get partial blobs.
+func (s FileSystemStore) Put(_ context.Context, key string, data []byte) error {
+	tmp, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// Get reads the file of the given key.
+func (s FileSystemStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+	return data, err
+}
+
+// path retrieves the file path of the given key. Keys are escaped, so they never point outside the store directory.
+func (s FileSystemStore) path(key string) string {
+	name := url.PathEscape(key)
+	if strings.Trim(name, ".") == "" {
+		// empty keys and relative path elements (e.g. `..`)
+		name = strings.ReplaceAll(name, ".", "%2E") + "%00"
+	}
+	return filepath.Join(s.dir, name)
+}
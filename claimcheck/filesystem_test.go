@@ -0,0 +1,42 @@
+package claimcheck_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neutrinocorp/streams/claimcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSystemStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "blobs")
+	store, err := claimcheck.NewFileSystemStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = store.Get(ctx, "foo-stream/1")
+	assert.ErrorIs(t, err, claimcheck.ErrBlobNotFound)
+
+	require.NoError(t, store.Put(ctx, "foo-stream/1", []byte("foo")))
+	require.NoError(t, store.Put(ctx, "foo-stream/1", []byte("bar")))
+	data, err := store.Get(ctx, "foo-stream/1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bar"), data)
+
+	// keys never point outside the store directory
+	for _, key := range []string{"..", "../foo", "", "."} {
+		require.NoError(t, store.Put(ctx, key, []byte(key)))
+		data, err = store.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(key), data)
+	}
+	entries, err := os.ReadDir(filepath.Dir(dir))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 5)
+}
@@ -0,0 +1,20 @@
+package claimcheck
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBlobNotFound the requested blob does not exist in the BlobStore.
+var ErrBlobNotFound = errors.New("streams: Claim-check blob not found")
+
+// BlobStore is a storage of message payloads (blobs) moved out of messages by the claim-check Writer.
+//
+// Blobs are never deleted by streams as a message might be read by several reader groups; implementations SHOULD
+// expire them (e.g. object storage lifecycle rules).
+type BlobStore interface {
+	// Put stores the given data under the given key, replacing any previous blob.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get retrieves the data stored under the given key. Returns ErrBlobNotFound if there is no such blob.
+	Get(ctx context.Context, key string) ([]byte, error)
+}
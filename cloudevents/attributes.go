@@ -0,0 +1,129 @@
+package cloudevents
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+)
+
+// CloudEvents context attribute names.
+const (
+	AttributeSpecVersion     = "specversion"
+	AttributeID              = "id"
+	AttributeSource          = "source"
+	AttributeType            = "type"
+	AttributeDataContentType = "datacontenttype"
+	AttributeDataSchema      = "dataschema"
+	AttributeSubject         = "subject"
+	AttributeTime            = "time"
+	AttributeData            = "data"
+	AttributeDataBase64      = "data_base64"
+)
+
+// streams-specific extension attribute names.
+const (
+	ExtensionStream            = "stream"
+	ExtensionStreamVersion     = "streamversion"
+	ExtensionDataSchemaVersion = "dataschemaversion"
+	ExtensionCorrelationID     = "correlationid"
+	ExtensionCausationID       = "causationid"
+	ExtensionPartitionKey      = "partitionkey" // as defined by the CloudEvents partitioning extension
+	ExtensionDeduplicationID   = "deduplicationid"
+	ExtensionDeliverAt         = "deliverat"
+)
+
+var (
+	// ErrInvalidSpecVersion the event specversion attribute is not supported.
+	ErrInvalidSpecVersion = errors.New("streams: Invalid CloudEvents specversion attribute")
+	// ErrMissingID the event id attribute is missing.
+	ErrMissingID = errors.New("streams: Missing CloudEvents id attribute")
+	// ErrMissingSource the event source attribute is missing.
+	ErrMissingSource = errors.New("streams: Missing CloudEvents source attribute")
+	// ErrMissingType the event type attribute is missing.
+	ErrMissingType = errors.New("streams: Missing CloudEvents type attribute")
+	// ErrInvalidTime the event time attribute is not a valid RFC 3339 timestamp.
+	ErrInvalidTime = errors.New("streams: Invalid CloudEvents time attribute")
+	// ErrInvalidExtensionName the event has an extension attribute with an invalid name (only lower-case
+	// alphanumeric characters are allowed) or with the name of a context attribute.
+	ErrInvalidExtensionName = errors.New("streams: Invalid CloudEvents extension attribute name")
+	// ErrInvalidEvent the encoded event is malformed.
+	ErrInvalidEvent = errors.New("streams: Invalid CloudEvents event")
+)
+
+var reservedAttributes = map[string]struct{}{
+	AttributeSpecVersion:       {},
+	AttributeID:                {},
+	AttributeSource:            {},
+	AttributeType:              {},
+	AttributeDataContentType:   {},
+	AttributeDataSchema:        {},
+	AttributeSubject:           {},
+	AttributeTime:              {},
+	AttributeData:              {},
+	AttributeDataBase64:        {},
+	ExtensionStream:            {},
+	ExtensionStreamVersion:     {},
+	ExtensionDataSchemaVersion: {},
+	ExtensionCorrelationID:     {},
+	ExtensionCausationID:       {},
+	ExtensionPartitionKey:      {},
+	ExtensionDeduplicationID:   {},
+	ExtensionDeliverAt:         {},
+}
+
+// Validate checks the given message complies with CloudEvents required attributes and naming conventions.
+func Validate(message streams.Message) error {
+	switch {
+	case message.SpecVersion != streams.CloudEventsSpecVersion:
+		return ErrInvalidSpecVersion
+	case message.ID == "":
+		return ErrMissingID
+	case message.Source == "":
+		return ErrMissingSource
+	case message.Type == "":
+		return ErrMissingType
+	}
+	if message.Timestamp != "" {
+		if _, err := time.Parse(time.RFC3339, message.Timestamp); err != nil {
+			return ErrInvalidTime
+		}
+	}
+	for k := range message.Headers {
+		if !isValidExtensionName(k) {
+			return ErrInvalidExtensionName
+		}
+	}
+	return nil
+}
+
+// isValidExtensionName reports whether the given name consists of lower-case alphanumeric characters only and does
+// not collide with any context attribute.
+func isValidExtensionName(name string) bool {
+	if name == "" {
+		return false
+	} else if _, ok := reservedAttributes[name]; ok {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isJSONContentType reports whether the given RFC 2046 content type holds JSON data. An empty content type is
+// considered JSON as CloudEvents specifies.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(strings.ToLower(contentType))
+	return contentType == streams.MarshalerJSONContentType || contentType == "text/json" ||
+		strings.HasSuffix(contentType, "+json")
+}
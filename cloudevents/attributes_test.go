@@ -0,0 +1,95 @@
+package cloudevents_test
+
+import (
+	"testing"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/cloudevents"
+	"github.com/stretchr/testify/assert"
+)
+
+func newValidMessage() streams.Message {
+	return streams.Message{
+		Stream:            "foo-stream",
+		StreamVersion:     2,
+		ID:                "123",
+		Source:            "org.neutrino.foo",
+		SpecVersion:       streams.CloudEventsSpecVersion,
+		Type:              "org.neutrino.foo.created",
+		Data:              []byte(`{"foo":"bar"}`),
+		DataContentType:   "application/json",
+		DataSchema:        "https://schemas.neutrino.org/foo",
+		DataSchemaVersion: 3,
+		Timestamp:         "2022-05-04T18:33:01Z",
+		Subject:           "foo",
+		CorrelationID:     "abc",
+		CausationID:       "def",
+		PartitionKey:      "foo-key",
+		DeduplicationID:   "ghi",
+		DeliverAt:         "2022-05-04T19:33:01Z",
+		Headers: map[string]string{
+			"tenantid": "neutrino",
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Mutate func(*streams.Message)
+		Err    error
+	}{
+		{
+			Name:   "Valid",
+			Mutate: func(_ *streams.Message) {},
+		},
+		{
+			Name:   "Invalid spec version",
+			Mutate: func(m *streams.Message) { m.SpecVersion = "0.3" },
+			Err:    cloudevents.ErrInvalidSpecVersion,
+		},
+		{
+			Name:   "Missing id",
+			Mutate: func(m *streams.Message) { m.ID = "" },
+			Err:    cloudevents.ErrMissingID,
+		},
+		{
+			Name:   "Missing source",
+			Mutate: func(m *streams.Message) { m.Source = "" },
+			Err:    cloudevents.ErrMissingSource,
+		},
+		{
+			Name:   "Missing type",
+			Mutate: func(m *streams.Message) { m.Type = "" },
+			Err:    cloudevents.ErrMissingType,
+		},
+		{
+			Name:   "Invalid time",
+			Mutate: func(m *streams.Message) { m.Timestamp = "yesterday" },
+			Err:    cloudevents.ErrInvalidTime,
+		},
+		{
+			Name:   "Upper-case extension",
+			Mutate: func(m *streams.Message) { m.Headers["TenantID"] = "foo" },
+			Err:    cloudevents.ErrInvalidExtensionName,
+		},
+		{
+			Name:   "Non alphanumeric extension",
+			Mutate: func(m *streams.Message) { m.Headers["tenant-id"] = "foo" },
+			Err:    cloudevents.ErrInvalidExtensionName,
+		},
+		{
+			Name:   "Reserved extension",
+			Mutate: func(m *streams.Message) { m.Headers["subject"] = "foo" },
+			Err:    cloudevents.ErrInvalidExtensionName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			msg := newValidMessage()
+			tt.Mutate(&msg)
+			assert.ErrorIs(t, cloudevents.Validate(msg), tt.Err)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package cloudevents
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/neutrinocorp/streams"
+)
+
+// HeaderPrefix prefix of HTTP headers holding CloudEvents attributes in binary content mode.
+const HeaderPrefix = "ce-"
+
+const headerContentType = "Content-Type"
+
+// EncodeBinary encodes the given message into a CloudEvent using HTTP binary mode.
+//
+// Context attributes, streams extensions and message headers are set as ce-prefixed HTTP headers while message data
+// is used as HTTP body as is. The datacontenttype attribute is mapped to the Content-Type HTTP header.
+func EncodeBinary(message streams.Message) (http.Header, []byte, error) {
+	if err := Validate(message); err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	for k, v := range message.Headers {
+		setBinaryHeader(header, k, v)
+	}
+	setBinaryHeader(header, AttributeSpecVersion, message.SpecVersion)
+	setBinaryHeader(header, AttributeID, message.ID)
+	setBinaryHeader(header, AttributeSource, message.Source)
+	setBinaryHeader(header, AttributeType, message.Type)
+	setBinaryHeader(header, AttributeDataSchema, message.DataSchema)
+	setBinaryHeader(header, AttributeSubject, message.Subject)
+	setBinaryHeader(header, AttributeTime, message.Timestamp)
+	setBinaryHeader(header, ExtensionStream, message.Stream)
+	setBinaryHeader(header, ExtensionCorrelationID, message.CorrelationID)
+	setBinaryHeader(header, ExtensionCausationID, message.CausationID)
+	setBinaryHeader(header, ExtensionPartitionKey, message.PartitionKey)
+	setBinaryHeader(header, ExtensionDeduplicationID, message.DeduplicationID)
+	setBinaryHeader(header, ExtensionDeliverAt, message.DeliverAt)
+	if message.StreamVersion != 0 {
+		setBinaryHeader(header, ExtensionStreamVersion, strconv.Itoa(message.StreamVersion))
+	}
+	if message.DataSchemaVersion != 0 {
+		setBinaryHeader(header, ExtensionDataSchemaVersion, strconv.Itoa(message.DataSchemaVersion))
+	}
+	if message.DataContentType != "" {
+		header.Set(headerContentType, message.DataContentType)
+	}
+	return header, message.Data, nil
+}
+
+func setBinaryHeader(header http.Header, key, value string) {
+	if value != "" {
+		header.Set(HeaderPrefix+key, encodeHeaderValue(value))
+	}
+}
+
+// DecodeBinary decodes the given CloudEvent encoded using HTTP binary mode into a message.
+//
+// Unknown ce-prefixed HTTP headers are decoded as message headers; non-CloudEvents HTTP headers are ignored.
+func DecodeBinary(header http.Header, body []byte) (streams.Message, error) {
+	message := streams.Message{
+		DataContentType: header.Get(headerContentType),
+	}
+	if len(body) > 0 {
+		message.Data = body
+	}
+	for k, v := range header {
+		key := strings.ToLower(k)
+		if !strings.HasPrefix(key, HeaderPrefix) || len(v) == 0 {
+			continue
+		}
+		value, err := url.PathUnescape(v[0])
+		if err != nil {
+			return streams.Message{}, ErrInvalidEvent
+		}
+		key = strings.TrimPrefix(key, HeaderPrefix)
+		if key == AttributeDataContentType || key == AttributeData || key == AttributeDataBase64 {
+			// not allowed in binary mode, Content-Type HTTP header and body are used instead
+			return streams.Message{}, ErrInvalidEvent
+		}
+		if err = setAttribute(&message, key, value); err != nil {
+			return streams.Message{}, err
+		}
+	}
+	if err := Validate(message); err != nil {
+		return streams.Message{}, err
+	}
+	return message, nil
+}
+
+// DecodeHTTP decodes the given CloudEvent received through HTTP into a message, detecting its content mode
+// from the Content-Type HTTP header.
+func DecodeHTTP(header http.Header, body []byte) (streams.Message, error) {
+	if IsStructuredContentType(header.Get(headerContentType)) {
+		return UnmarshalStructured(body)
+	}
+	return DecodeBinary(header, body)
+}
+
+// IsStructuredContentType reports whether the given HTTP content type holds a CloudEvent encoded using
+// JSON structured mode.
+func IsStructuredContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == ContentTypeStructuredJSON
+}
+
+// encodeHeaderValue percent-encodes characters not allowed in HTTP header values as CloudEvents HTTP protocol
+// binding specifies (space, double quote, percent and non-printable ASCII characters).
+func encodeHeaderValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c == '"' || c == '%' || c >= 0x7f {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(strconv.FormatUint(uint64(c)>>4, 16)))
+			b.WriteString(strings.ToUpper(strconv.FormatUint(uint64(c)&0xf, 16)))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package cloudevents_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/cloudevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBinary(t *testing.T) {
+	msg := newValidMessage()
+	msg.Subject = "foo bar%"
+	header, body, err := cloudevents.EncodeBinary(msg)
+	require.NoError(t, err)
+	assert.Equal(t, msg.Data, body)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "1.0", header.Get("ce-specversion"))
+	assert.Equal(t, "123", header.Get("ce-id"))
+	assert.Equal(t, "foo%20bar%25", header.Get("ce-subject"))
+	assert.Equal(t, "2", header.Get("ce-streamversion"))
+	assert.Equal(t, "neutrino", header.Get("ce-tenantid"))
+	assert.Equal(t, "foo-key", header.Get("ce-partitionkey"))
+	assert.Equal(t, "ghi", header.Get("ce-deduplicationid"))
+	assert.Equal(t, "2022-05-04T19:33:01Z", header.Get("ce-deliverat"))
+	assert.Empty(t, header.Get("ce-datacontenttype"))
+
+	out, err := cloudevents.DecodeBinary(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, msg, out)
+
+	_, _, err = cloudevents.EncodeBinary(streams.Message{})
+	assert.ErrorIs(t, err, cloudevents.ErrInvalidSpecVersion)
+}
+
+func TestDecodeBinary(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Header http.Header
+		Exp    streams.Message
+		Err    error
+	}{
+		{
+			Name:   "Missing spec version",
+			Header: http.Header{"Ce-Id": {"1"}, "Ce-Source": {"foo"}, "Ce-Type": {"bar"}},
+			Err:    cloudevents.ErrInvalidSpecVersion,
+		},
+		{
+			Name: "Missing type",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"},
+				"Ce-Source": {"foo"}},
+			Err: cloudevents.ErrMissingType,
+		},
+		{
+			Name: "Content type as attribute",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Ce-Datacontenttype": {"application/json"}},
+			Err: cloudevents.ErrInvalidEvent,
+		},
+		{
+			Name: "Invalid percent-encoding",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Ce-Subject": {"%zz"}},
+			Err: cloudevents.ErrInvalidEvent,
+		},
+		{
+			Name: "Ignores non CloudEvents headers",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Authorization": {"Bearer abc"}, "Ce-Traceparent": {"00-abc"}},
+			Exp: streams.Message{
+				ID:          "1",
+				Source:      "foo",
+				SpecVersion: "1.0",
+				Type:        "bar",
+				Headers:     map[string]string{"traceparent": "00-abc"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			out, err := cloudevents.DecodeBinary(tt.Header, nil)
+			assert.ErrorIs(t, err, tt.Err)
+			assert.Equal(t, tt.Exp, out)
+		})
+	}
+}
+
+func TestDecodeHTTP(t *testing.T) {
+	msg := newValidMessage()
+	data, err := cloudevents.MarshalStructured(msg)
+	require.NoError(t, err)
+	out, err := cloudevents.DecodeHTTP(http.Header{
+		"Content-Type": {"application/cloudevents+json; charset=utf-8"},
+	}, data)
+	require.NoError(t, err)
+	assert.Equal(t, msg, out)
+
+	header, body, err := cloudevents.EncodeBinary(msg)
+	require.NoError(t, err)
+	out, err = cloudevents.DecodeHTTP(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, msg, out)
+}
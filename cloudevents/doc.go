@@ -0,0 +1,7 @@
+// Package cloudevents contains codecs to encode and decode streams.Message using CNCF's CloudEvents specification
+// (version 1.0) content modes, enabling interoperability with non-streams CloudEvents producers and consumers.
+//
+// Supported content modes are JSON structured mode and HTTP binary mode.
+//
+// For more information, please look: https://github.com/cloudevents/spec
+package cloudevents
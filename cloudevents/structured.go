@@ -0,0 +1,192 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/neutrinocorp/streams"
+)
+
+// ContentTypeStructuredJSON content type of CloudEvents encoded using JSON structured mode.
+const ContentTypeStructuredJSON = "application/cloudevents+json"
+
+// sorts object keys so encoded events are deterministic
+var jsonCodec = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// MarshalStructured encodes the given message into a CloudEvent using JSON structured mode.
+//
+// Message data is embedded as JSON if its content type is JSON-based and data is valid JSON; otherwise, data is
+// embedded as base64 (data_base64 attribute). Message headers are encoded as extension attributes.
+func MarshalStructured(message streams.Message) ([]byte, error) {
+	if err := Validate(message); err != nil {
+		return nil, err
+	}
+
+	event := make(map[string]interface{}, 15+len(message.Headers))
+	for k, v := range message.Headers {
+		event[k] = v
+	}
+	event[AttributeSpecVersion] = message.SpecVersion
+	event[AttributeID] = message.ID
+	event[AttributeSource] = message.Source
+	event[AttributeType] = message.Type
+	setOptionalAttribute(event, AttributeDataContentType, message.DataContentType)
+	setOptionalAttribute(event, AttributeDataSchema, message.DataSchema)
+	setOptionalAttribute(event, AttributeSubject, message.Subject)
+	setOptionalAttribute(event, AttributeTime, message.Timestamp)
+	setOptionalAttribute(event, ExtensionStream, message.Stream)
+	setOptionalAttribute(event, ExtensionCorrelationID, message.CorrelationID)
+	setOptionalAttribute(event, ExtensionCausationID, message.CausationID)
+	setOptionalAttribute(event, ExtensionPartitionKey, message.PartitionKey)
+	setOptionalAttribute(event, ExtensionDeduplicationID, message.DeduplicationID)
+	setOptionalAttribute(event, ExtensionDeliverAt, message.DeliverAt)
+	if message.StreamVersion != 0 {
+		event[ExtensionStreamVersion] = strconv.Itoa(message.StreamVersion)
+	}
+	if message.DataSchemaVersion != 0 {
+		event[ExtensionDataSchemaVersion] = strconv.Itoa(message.DataSchemaVersion)
+	}
+
+	if len(message.Data) > 0 {
+		if isJSONContentType(message.DataContentType) && jsonCodec.Valid(message.Data) {
+			event[AttributeData] = jsoniter.RawMessage(message.Data)
+		} else {
+			event[AttributeDataBase64] = base64.StdEncoding.EncodeToString(message.Data)
+		}
+	}
+	return jsonCodec.Marshal(event)
+}
+
+func setOptionalAttribute(event map[string]interface{}, key, value string) {
+	if value != "" {
+		event[key] = value
+	}
+}
+
+// UnmarshalStructured decodes the given CloudEvent encoded using JSON structured mode into a message.
+//
+// Unknown extension attributes are decoded as message headers.
+func UnmarshalStructured(data []byte) (streams.Message, error) {
+	event := map[string]jsoniter.RawMessage{}
+	if err := jsonCodec.Unmarshal(data, &event); err != nil {
+		return streams.Message{}, ErrInvalidEvent
+	}
+
+	message := streams.Message{}
+	var (
+		err     error
+		rawData jsoniter.RawMessage
+	)
+	for k, v := range event {
+		switch k {
+		case AttributeData:
+			// decoding depends on the content type, which might not be decoded yet
+			rawData = v
+		case AttributeDataBase64:
+			var encoded string
+			if err = jsonCodec.Unmarshal(v, &encoded); err != nil {
+				return streams.Message{}, ErrInvalidEvent
+			}
+			if message.Data, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+				return streams.Message{}, ErrInvalidEvent
+			}
+		default:
+			var value string
+			if value, err = decodeStructuredAttribute(v); err != nil {
+				return streams.Message{}, err
+			}
+			if err = setAttribute(&message, k, value); err != nil {
+				return streams.Message{}, err
+			}
+		}
+	}
+	if rawData != nil {
+		message.Data = decodeStructuredData(rawData, message.DataContentType)
+	}
+	if err = Validate(message); err != nil {
+		return streams.Message{}, err
+	}
+	return message, nil
+}
+
+// decodeStructuredData retrieves the raw JSON value of the data attribute. If the content type is not JSON-based and
+// the value is a JSON string, the string itself is retrieved as CloudEvents specifies.
+func decodeStructuredData(raw jsoniter.RawMessage, contentType string) []byte {
+	if isJSONContentType(contentType) {
+		return raw
+	}
+	var str string
+	if err := jsonCodec.Unmarshal(raw, &str); err == nil {
+		return []byte(str)
+	}
+	return raw
+}
+
+// decodeStructuredAttribute converts a context attribute value into its string representation. CloudEvents
+// attributes might be encoded as JSON strings, numbers or booleans.
+func decodeStructuredAttribute(raw jsoniter.RawMessage) (string, error) {
+	var value interface{}
+	if err := jsonCodec.Unmarshal(raw, &value); err != nil {
+		return "", ErrInvalidEvent
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	default:
+		return "", ErrInvalidEvent
+	}
+}
+
+// setAttribute sets a decoded context attribute into the given message.
+func setAttribute(message *streams.Message, key, value string) (err error) {
+	switch key {
+	case AttributeSpecVersion:
+		message.SpecVersion = value
+	case AttributeID:
+		message.ID = value
+	case AttributeSource:
+		message.Source = value
+	case AttributeType:
+		message.Type = value
+	case AttributeDataContentType:
+		message.DataContentType = value
+	case AttributeDataSchema:
+		message.DataSchema = value
+	case AttributeSubject:
+		message.Subject = value
+	case AttributeTime:
+		message.Timestamp = value
+	case ExtensionStream:
+		message.Stream = value
+	case ExtensionCorrelationID:
+		message.CorrelationID = value
+	case ExtensionCausationID:
+		message.CausationID = value
+	case ExtensionPartitionKey:
+		message.PartitionKey = value
+	case ExtensionDeduplicationID:
+		message.DeduplicationID = value
+	case ExtensionDeliverAt:
+		message.DeliverAt = value
+	case ExtensionStreamVersion:
+		message.StreamVersion, err = strconv.Atoi(value)
+	case ExtensionDataSchemaVersion:
+		message.DataSchemaVersion, err = strconv.Atoi(value)
+	default:
+		if message.Headers == nil {
+			message.Headers = map[string]string{}
+		}
+		message.Headers[key] = value
+	}
+	if err != nil {
+		return ErrInvalidEvent
+	}
+	return nil
+}
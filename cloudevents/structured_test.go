@@ -0,0 +1,144 @@
+package cloudevents_test
+
+import (
+	"testing"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/cloudevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalStructured(t *testing.T) {
+	msg := newValidMessage()
+	data, err := cloudevents.MarshalStructured(msg)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"specversion":"1.0",
+		"id":"123",
+		"source":"org.neutrino.foo",
+		"type":"org.neutrino.foo.created",
+		"datacontenttype":"application/json",
+		"dataschema":"https://schemas.neutrino.org/foo",
+		"subject":"foo",
+		"time":"2022-05-04T18:33:01Z",
+		"stream":"foo-stream",
+		"streamversion":"2",
+		"dataschemaversion":"3",
+		"correlationid":"abc",
+		"causationid":"def",
+		"partitionkey":"foo-key",
+		"deduplicationid":"ghi",
+		"deliverat":"2022-05-04T19:33:01Z",
+		"tenantid":"neutrino",
+		"data":{"foo":"bar"}
+	}`, string(data))
+
+	out, err := cloudevents.UnmarshalStructured(data)
+	require.NoError(t, err)
+	assert.Equal(t, msg, out)
+
+	_, err = cloudevents.MarshalStructured(streams.Message{})
+	assert.ErrorIs(t, err, cloudevents.ErrInvalidSpecVersion)
+}
+
+func TestMarshalStructured_Base64(t *testing.T) {
+	msg := newValidMessage()
+	msg.DataContentType = "application/avro"
+	msg.Data = []byte{0x00, 0x01, 0xff}
+	data, err := cloudevents.MarshalStructured(msg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"data_base64":"AAH/"`)
+	assert.NotContains(t, string(data), `"data":`)
+
+	out, err := cloudevents.UnmarshalStructured(data)
+	require.NoError(t, err)
+	assert.Equal(t, msg.Data, out.Data)
+
+	// JSON content type holding non-JSON data
+	msg.DataContentType = ""
+	msg.Data = []byte("foo")
+	data, err = cloudevents.MarshalStructured(msg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"data_base64":"Zm9v"`)
+}
+
+func TestMarshalStructured_JSONString(t *testing.T) {
+	msg := newValidMessage()
+	msg.Data = []byte(`"hello"`)
+	data, err := cloudevents.MarshalStructured(msg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"data":"hello"`)
+
+	// JSON string data is kept as JSON
+	out, err := cloudevents.UnmarshalStructured(data)
+	require.NoError(t, err)
+	assert.Equal(t, msg, out)
+}
+
+func TestUnmarshalStructured(t *testing.T) {
+	tests := []struct {
+		Name string
+		In   string
+		Exp  streams.Message
+		Err  error
+	}{
+		{
+			Name: "Malformed",
+			In:   `{"specversion":`,
+			Err:  cloudevents.ErrInvalidEvent,
+		},
+		{
+			Name: "Missing id",
+			In:   `{"specversion":"1.0","source":"foo","type":"bar"}`,
+			Err:  cloudevents.ErrMissingID,
+		},
+		{
+			Name: "Unsupported spec version",
+			In:   `{"specversion":"0.3","id":"1","source":"foo","type":"bar"}`,
+			Err:  cloudevents.ErrInvalidSpecVersion,
+		},
+		{
+			Name: "Invalid stream version",
+			In:   `{"specversion":"1.0","id":"1","source":"foo","type":"bar","streamversion":"two"}`,
+			Err:  cloudevents.ErrInvalidEvent,
+		},
+		{
+			Name: "Invalid data base64",
+			In:   `{"specversion":"1.0","id":"1","source":"foo","type":"bar","data_base64":"%%"}`,
+			Err:  cloudevents.ErrInvalidEvent,
+		},
+		{
+			Name: "Object extension",
+			In:   `{"specversion":"1.0","id":"1","source":"foo","type":"bar","ext":{"a":1}}`,
+			Err:  cloudevents.ErrInvalidEvent,
+		},
+		{
+			Name: "Invalid extension name",
+			In:   `{"specversion":"1.0","id":"1","source":"foo","type":"bar","trace_id":"abc"}`,
+			Err:  cloudevents.ErrInvalidExtensionName,
+		},
+		{
+			Name: "Minimal with typed extensions and string data",
+			In: `{"specversion":"1.0","id":"1","source":"foo","type":"bar","datacontenttype":"text/plain",
+				"priority":5,"sampled":true,"data":"hello"}`,
+			Exp: streams.Message{
+				ID:              "1",
+				Source:          "foo",
+				SpecVersion:     "1.0",
+				Type:            "bar",
+				DataContentType: "text/plain",
+				Data:            []byte("hello"),
+				Headers:         map[string]string{"priority": "5", "sampled": "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			out, err := cloudevents.UnmarshalStructured([]byte(tt.In))
+			assert.ErrorIs(t, err, tt.Err)
+			assert.Equal(t, tt.Exp, out)
+		})
+	}
+}
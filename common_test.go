@@ -57,7 +57,7 @@ func (h hashing64AlgorithmFailingNoop) Sum64() uint64 {
 
 type writerNoopHook struct {
 	onWrite      func(context.Context, streams.Message) error
-	onWriteBatch func(context.Context, ...streams.Message) (uint32, error)
+	onWriteBatch func(context.Context, ...streams.Message) (streams.BatchResult, error)
 }
 
 var _ streams.Writer = writerNoopHook{}
@@ -69,9 +69,47 @@ func (p writerNoopHook) Write(ctx context.Context, message streams.Message) erro
 	return nil
 }
 
-func (p writerNoopHook) WriteBatch(ctx context.Context, messages ...streams.Message) (uint32, error) {
+func (p writerNoopHook) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
 	if p.onWriteBatch != nil {
 		return p.onWriteBatch(ctx, messages...)
 	}
-	return 0, nil
+	return streams.NewBatchResult(messages), nil
+}
+
+type readerShutdownHook struct {
+	onExecuteTask func(context.Context, streams.ReaderTask) error
+	onShutdown    func(context.Context) error
+}
+
+var (
+	_ streams.Reader     = &readerShutdownHook{}
+	_ streams.Shutdowner = &readerShutdownHook{}
+)
+
+func (r *readerShutdownHook) ExecuteTask(ctx context.Context, task streams.ReaderTask) error {
+	if r.onExecuteTask != nil {
+		return r.onExecuteTask(ctx, task)
+	}
+	return nil
+}
+
+func (r *readerShutdownHook) Shutdown(ctx context.Context) error {
+	if r.onShutdown != nil {
+		return r.onShutdown(ctx)
+	}
+	return nil
+}
+
+type writerShutdownHook struct {
+	writerNoopHook
+	onShutdown func(context.Context) error
+}
+
+var _ streams.Shutdowner = writerShutdownHook{}
+
+func (w writerShutdownHook) Shutdown(ctx context.Context) error {
+	if w.onShutdown != nil {
+		return w.onShutdown(ctx)
+	}
+	return nil
 }
@@ -0,0 +1,179 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Algorithm compression algorithm applied to message data.
+type Algorithm uint8
+
+const (
+	// Gzip compresses data using gzip (RFC 1952).
+	Gzip Algorithm = iota + 1
+	// Zstd compresses data using Zstandard (RFC 8878).
+	Zstd
+	// Snappy compresses data using the Snappy framing format.
+	Snappy
+)
+
+var (
+	// ErrUnknownAlgorithm the compression algorithm is not supported.
+	ErrUnknownAlgorithm = errors.New("streams: Unknown compression algorithm")
+	// ErrMaxSizeExceeded the decompressed data exceeds the maximum size of the Marshaler.
+	ErrMaxSizeExceeded = errors.New("streams: Decompressed data exceeds maximum size")
+)
+
+// String retrieves the content encoding name of the algorithm (e.g. gzip).
+func (a Algorithm) String() string {
+	switch a {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	case Snappy:
+		return "snappy"
+	default:
+		return ""
+	}
+}
+
+// magic number each compressed payload starts with, used to detect the algorithm of incoming data.
+var (
+	gzipMagic   = []byte{0x1f, 0x8b, 0x08}
+	zstdMagic   = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	snappyMagic = []byte("\xff\x06\x00\x00sNaPpY")
+)
+
+// detectAlgorithm retrieves the algorithm the given data was compressed with. Returns false if the data is not
+// compressed.
+func detectAlgorithm(data []byte) (Algorithm, bool) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return Gzip, true
+	case bytes.HasPrefix(data, zstdMagic):
+		return Zstd, true
+	case bytes.HasPrefix(data, snappyMagic):
+		return Snappy, true
+	default:
+		return 0, false
+	}
+}
+
+var (
+	// zstd encoders and decoders are expensive to allocate and safe for concurrent EncodeAll/DecodeAll calls
+	zstdEncoder      *zstd.Encoder
+	zstdErr          error
+	onceZstdEncoder  sync.Once
+	gzipWriterPool   = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+	snappyWriterPool = sync.Pool{New: func() interface{} { return snappy.NewBufferedWriter(nil) }}
+	// key: Maximum decoded size | value: *zstd.Decoder
+	zstdDecoders sync.Map
+)
+
+func initZstdEncoder() error {
+	onceZstdEncoder.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+	})
+	return zstdErr
+}
+
+// getZstdDecoder retrieves the shared zstd decoder limited to the given maximum decoded size.
+func getZstdDecoder(maxSize int64) (*zstd.Decoder, error) {
+	if d, ok := zstdDecoders.Load(maxSize); ok {
+		return d.(*zstd.Decoder), nil
+	}
+	d, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(maxSize)))
+	if err != nil {
+		return nil, err
+	}
+	if prev, loaded := zstdDecoders.LoadOrStore(maxSize, d); loaded {
+		d.Close()
+		return prev.(*zstd.Decoder), nil
+	}
+	return d, nil
+}
+
+// compress compresses the given data using the given algorithm.
+func compress(a Algorithm, data []byte) ([]byte, error) {
+	switch a {
+	case Gzip:
+		buf := bytes.Buffer{}
+		w := gzipWriterPool.Get().(*gzip.Writer)
+		defer gzipWriterPool.Put(w)
+		w.Reset(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		if err := initZstdEncoder(); err != nil {
+			return nil, err
+		}
+		return zstdEncoder.EncodeAll(data, make([]byte, 0, len(data))), nil
+	case Snappy:
+		buf := bytes.Buffer{}
+		w := snappyWriterPool.Get().(*snappy.Writer)
+		defer snappyWriterPool.Put(w)
+		w.Reset(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
+
+// decompress decompresses the given data using the given algorithm. Returns ErrMaxSizeExceeded if the decompressed
+// data exceeds the given maximum size.
+func decompress(a Algorithm, data []byte, maxSize int64) ([]byte, error) {
+	switch a {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readAllLimited(r, maxSize)
+	case Zstd:
+		d, err := getZstdDecoder(maxSize)
+		if err != nil {
+			return nil, err
+		}
+		out, err := d.DecodeAll(data, nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+			return nil, ErrMaxSizeExceeded
+		}
+		return out, err
+	case Snappy:
+		return readAllLimited(snappy.NewReader(bytes.NewReader(data)), maxSize)
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
+
+// readAllLimited reads the given reader until EOF. Returns ErrMaxSizeExceeded if it holds more than the given
+// maximum size, without reading further.
+func readAllLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	} else if int64(len(data)) > maxSize {
+		return nil, ErrMaxSizeExceeded
+	}
+	return data, nil
+}
@@ -0,0 +1,87 @@
+// Package compression implements a streams.Marshaler decorator compressing message data (gzip, Zstandard or Snappy),
+// reducing broker costs and payload-limit pressure on large messages.
+//
+// Compressed messages are labeled with the HeaderContentEncoding header. On the reader side, the algorithm is
+// detected from the data itself (magic number) as streams.Marshaler receives no message headers, so a single
+// Marshaler decodes messages compressed with any supported algorithm (e.g. while rolling out a new one) and plain,
+// uncompressed messages. Thus, the decorated Marshaler MUST NOT produce plain data starting with a magic number of a
+// supported algorithm (text formats such as JSON never do), otherwise it is mistaken for compressed data.
+//
+// Decompressed data is capped (WithMaxDecompressedSize), so crafted messages (decompression bombs) are rejected.
+package compression
+
+import (
+	"github.com/neutrinocorp/streams"
+)
+
+// HeaderContentEncoding message header holding the compression algorithm applied to message data (e.g. gzip).
+const HeaderContentEncoding = "contentencoding"
+
+// Marshaler is a streams.Marshaler decorator compressing the output of the decorated Marshaler using an Algorithm.
+type Marshaler struct {
+	next      streams.Marshaler
+	algorithm Algorithm
+	maxSize   int64
+}
+
+var _ streams.HeadersMarshaler = Marshaler{}
+
+// NewMarshaler allocates a new Marshaler compressing the output of the given streams.Marshaler using the given
+// Algorithm.
+func NewMarshaler(m streams.Marshaler, a Algorithm, opts ...MarshalerOption) Marshaler {
+	baseOpts := marshalerOptions{
+		maxDecompressedSize: DefaultMaxDecompressedSize,
+	}
+	for _, o := range opts {
+		o.apply(&baseOpts)
+	}
+	return Marshaler{
+		next:      m,
+		algorithm: a,
+		maxSize:   baseOpts.maxDecompressedSize,
+	}
+}
+
+// Marshal transforms a complex data type into a compressed binary array.
+func (m Marshaler) Marshal(schemaDef string, data interface{}) ([]byte, error) {
+	encoded, err := m.next.Marshal(schemaDef, data)
+	if err != nil {
+		return nil, err
+	}
+	return compress(m.algorithm, encoded)
+}
+
+// Unmarshal decompresses the given binary array (if compressed) and transforms it into a complex data type.
+//
+// Returns ErrMaxSizeExceeded if the decompressed data exceeds the maximum size.
+func (m Marshaler) Unmarshal(schemaDef string, data []byte, ref interface{}) error {
+	if a, ok := detectAlgorithm(data); ok {
+		maxSize := m.maxSize
+		if maxSize <= 0 {
+			// zero-value Marshaler
+			maxSize = DefaultMaxDecompressedSize
+		}
+		var err error
+		if data, err = decompress(a, data, maxSize); err != nil {
+			return err
+		}
+	}
+	return m.next.Unmarshal(schemaDef, data, ref)
+}
+
+// ContentType retrieves the encoding/decoding format of the decorated Marshaler.
+func (m Marshaler) ContentType() string {
+	return m.next.ContentType()
+}
+
+// Headers retrieves the headers of the decorated Marshaler along with the HeaderContentEncoding header.
+func (m Marshaler) Headers() map[string]string {
+	headers := map[string]string{}
+	if marshaler, ok := m.next.(streams.HeadersMarshaler); ok {
+		for k, v := range marshaler.Headers() {
+			headers[k] = v
+		}
+	}
+	headers[HeaderContentEncoding] = m.algorithm.String()
+	return headers
+}
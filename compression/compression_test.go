@@ -0,0 +1,122 @@
+package compression_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/compression"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fooMessage struct {
+	Foo string `json:"foo"`
+}
+
+func TestMarshaler(t *testing.T) {
+	payload := fooMessage{Foo: string(bytes.Repeat([]byte("foo"), 256))}
+	plain, err := streams.JSONMarshaler{}.Marshal("", payload)
+	require.NoError(t, err)
+
+	for _, a := range []compression.Algorithm{compression.Gzip, compression.Zstd, compression.Snappy} {
+		t.Run(a.String(), func(t *testing.T) {
+			m := compression.NewMarshaler(streams.JSONMarshaler{}, a)
+			assert.Equal(t, streams.MarshalerJSONContentType, m.ContentType())
+			assert.Equal(t, map[string]string{compression.HeaderContentEncoding: a.String()}, m.Headers())
+
+			data, err := m.Marshal("", payload)
+			require.NoError(t, err)
+			assert.Less(t, len(data), len(plain))
+
+			out := fooMessage{}
+			require.NoError(t, m.Unmarshal("", data, &out))
+			assert.Equal(t, payload, out)
+
+			// data compressed with other algorithms and uncompressed data are decoded too
+			for _, other := range []compression.Algorithm{compression.Gzip, compression.Zstd, compression.Snappy} {
+				data, err = compression.NewMarshaler(streams.JSONMarshaler{}, other).Marshal("", payload)
+				require.NoError(t, err)
+				out = fooMessage{}
+				require.NoError(t, m.Unmarshal("", data, &out))
+				assert.Equal(t, payload, out)
+			}
+			out = fooMessage{}
+			require.NoError(t, m.Unmarshal("", plain, &out))
+			assert.Equal(t, payload, out)
+		})
+	}
+
+	_, err = compression.NewMarshaler(streams.JSONMarshaler{}, 0).Marshal("", payload)
+	assert.ErrorIs(t, err, compression.ErrUnknownAlgorithm)
+	_, err = compression.NewMarshaler(streams.FailingMarshalerNoop{}, compression.Gzip).Marshal("", payload)
+	assert.EqualError(t, err, "failing marshal")
+}
+
+func TestMarshaler_MaxDecompressedSize(t *testing.T) {
+	payload := fooMessage{Foo: string(bytes.Repeat([]byte("a"), 1<<20))}
+	for _, a := range []compression.Algorithm{compression.Gzip, compression.Zstd, compression.Snappy} {
+		t.Run(a.String(), func(t *testing.T) {
+			data, err := compression.NewMarshaler(streams.JSONMarshaler{}, a).Marshal("", payload)
+			require.NoError(t, err)
+
+			// highly compressible data does not exhaust the reader's memory
+			m := compression.NewMarshaler(streams.JSONMarshaler{}, a, compression.WithMaxDecompressedSize(1024))
+			out := fooMessage{}
+			assert.ErrorIs(t, m.Unmarshal("", data, &out), compression.ErrMaxSizeExceeded)
+
+			m = compression.NewMarshaler(streams.JSONMarshaler{}, a, compression.WithMaxDecompressedSize(2<<20))
+			require.NoError(t, m.Unmarshal("", data, &out))
+			assert.Equal(t, payload, out)
+		})
+	}
+}
+
+// headersMarshaler is a streams.HeadersMarshaler labeling data with a schema header.
+type headersMarshaler struct {
+	streams.JSONMarshaler
+}
+
+func (m headersMarshaler) Headers() map[string]string {
+	return map[string]string{"schema": "foo"}
+}
+
+func TestMarshaler_Hub(t *testing.T) {
+	hub := streams.NewHub(streams.WithMarshaler(compression.NewMarshaler(headersMarshaler{}, compression.Zstd)))
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	var written streams.Message
+	hub.Writer = writerFunc(func(_ context.Context, message streams.Message) error {
+		written = message
+		return nil
+	})
+	require.NoError(t, hub.Write(streams.ContextWithHeaders(context.Background(), map[string]string{
+		"tenantid": "foo",
+	}), fooMessage{Foo: "foo"}))
+	assert.Equal(t, map[string]string{
+		"tenantid":                        "foo",
+		"schema":                          "foo",
+		compression.HeaderContentEncoding: "zstd",
+	}, written.Headers)
+	assert.Equal(t, streams.MarshalerJSONContentType, written.DataContentType)
+
+	out := fooMessage{}
+	require.NoError(t, hub.Marshaler.Unmarshal("", written.Data, &out))
+	assert.Equal(t, fooMessage{Foo: "foo"}, out)
+}
+
+type writerFunc func(context.Context, streams.Message) error
+
+func (f writerFunc) Write(ctx context.Context, message streams.Message) error {
+	return f(ctx, message)
+}
+
+func (f writerFunc) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	for i, msg := range messages {
+		res[i].Err = f(ctx, msg)
+	}
+	return res, res.Err()
+}
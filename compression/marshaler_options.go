@@ -0,0 +1,31 @@
+package compression
+
+// DefaultMaxDecompressedSize default maximum size in bytes of decompressed message data (64 MiB).
+const DefaultMaxDecompressedSize int64 = 64 << 20
+
+type marshalerOptions struct {
+	maxDecompressedSize int64
+}
+
+// MarshalerOption enables configuration of a Marshaler instance.
+type MarshalerOption interface {
+	apply(*marshalerOptions)
+}
+
+type maxDecompressedSizeOption struct {
+	Size int64
+}
+
+func (o maxDecompressedSizeOption) apply(opts *marshalerOptions) {
+	if o.Size > 0 {
+		opts.maxDecompressedSize = o.Size
+	}
+}
+
+// WithMaxDecompressedSize sets the maximum size in bytes of decompressed message data. Larger data fails with
+// ErrMaxSizeExceeded, so crafted messages (decompression bombs) do not exhaust the reader's memory.
+//
+// Default is DefaultMaxDecompressedSize.
+func WithMaxDecompressedSize(n int64) MarshalerOption {
+	return maxDecompressedSizeOption{Size: n}
+}
@@ -12,6 +12,9 @@ const (
 	// ContextCausationID is reference of the last message processed. This helps to know a direct relation between
 	// a new process and the past one.
 	ContextCausationID MessageContextKey = "shub-causation-id"
+	// ContextHeaders holds extension attributes (CloudEvents extensions) to be attached to every message written
+	// using the context.
+	ContextHeaders MessageContextKey = "shub-headers"
 )
 
 // InjectMessageCorrelationID injects the correlation id from the given context if available. If not, it will use the
@@ -37,3 +40,42 @@ func InjectMessageCausationID(ctx context.Context, messageID string) string {
 
 	return messageID
 }
+
+// ContextWithHeaders returns a copy of the given context holding the given message headers, merged with headers
+// previously set in the context. Headers are attached to every message written using the returned context.
+func ContextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent, _ := ctx.Value(ContextHeaders).(map[string]string)
+	merged := make(map[string]string, len(parent)+len(headers))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, ContextHeaders, merged)
+}
+
+// InjectMessageHeaders merges headers from the given context (if available) with the given headers. Given headers
+// take precedence over context headers.
+//
+// Returns nil if no headers were found.
+func InjectMessageHeaders(ctx context.Context, headers map[string]string) map[string]string {
+	var ctxHeaders map[string]string
+	if ctx != nil {
+		ctxHeaders, _ = ctx.Value(ContextHeaders).(map[string]string)
+	}
+	if len(ctxHeaders) == 0 && len(headers) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(ctxHeaders)+len(headers))
+	for k, v := range ctxHeaders {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return merged
+}
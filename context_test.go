@@ -89,3 +89,19 @@ func TestInjectMessageCausationID(t *testing.T) {
 		assert.Equal(t, tt.Exp, id)
 	}
 }
+
+func TestContextWithHeaders(t *testing.T) {
+	ctx := streams.ContextWithHeaders(nil, map[string]string{"foo": "1"})
+	ctx = streams.ContextWithHeaders(ctx, map[string]string{"bar": "2"})
+	assert.Equal(t, map[string]string{"foo": "1", "bar": "2"}, ctx.Value(streams.ContextHeaders))
+}
+
+func TestInjectMessageHeaders(t *testing.T) {
+	assert.Nil(t, streams.InjectMessageHeaders(nil, nil))
+	assert.Nil(t, streams.InjectMessageHeaders(context.Background(), map[string]string{}))
+	assert.Equal(t, map[string]string{"foo": "1"}, streams.InjectMessageHeaders(nil, map[string]string{"foo": "1"}))
+
+	ctx := streams.ContextWithHeaders(context.Background(), map[string]string{"foo": "1", "bar": "2"})
+	assert.Equal(t, map[string]string{"foo": "3", "bar": "2"},
+		streams.InjectMessageHeaders(ctx, map[string]string{"foo": "3"}))
+}
@@ -0,0 +1,71 @@
+package amazon
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// maxMessageAttributes maximum number of message attributes allowed by Amazon SNS and SQS per message.
+	maxMessageAttributes = 10
+	stringAttributeType  = "String"
+)
+
+// newSqsMessageAttributes converts streams.Message headers into Amazon SQS message attributes.
+//
+// Returns nil if headers exceed the maximum number of attributes allowed by Amazon SQS. Nevertheless, headers are
+// always carried within the message body.
+func newSqsMessageAttributes(headers map[string]string) map[string]sqstypes.MessageAttributeValue {
+	if len(headers) == 0 || len(headers) > maxMessageAttributes {
+		return nil
+	}
+	attributes := make(map[string]sqstypes.MessageAttributeValue, len(headers))
+	for k, v := range headers {
+		attributes[k] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String(stringAttributeType),
+			StringValue: aws.String(v),
+		}
+	}
+	return attributes
+}
+
+// newSnsMessageAttributes converts streams.Message headers into Amazon SNS message attributes, so subscriptions
+// may use them in filter policies.
+//
+// Returns nil if headers exceed the maximum number of attributes allowed by Amazon SNS. Nevertheless, headers are
+// always carried within the message body.
+func newSnsMessageAttributes(headers map[string]string) map[string]snstypes.MessageAttributeValue {
+	if len(headers) == 0 || len(headers) > maxMessageAttributes {
+		return nil
+	}
+	attributes := make(map[string]snstypes.MessageAttributeValue, len(headers))
+	for k, v := range headers {
+		attributes[k] = snstypes.MessageAttributeValue{
+			DataType:    aws.String(stringAttributeType),
+			StringValue: aws.String(v),
+		}
+	}
+	return attributes
+}
+
+// mergeSqsMessageAttributes adds string Amazon SQS message attributes into the given headers, keeping headers
+// carried within the message body if both define the same key.
+func mergeSqsMessageAttributes(headers map[string]string,
+	attributes map[string]sqstypes.MessageAttributeValue) map[string]string {
+	for k, v := range attributes {
+		if v.DataType == nil || !strings.HasPrefix(*v.DataType, stringAttributeType) || v.StringValue == nil {
+			continue
+		}
+		if _, ok := headers[k]; ok {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(attributes))
+		}
+		headers[k] = *v.StringValue
+	}
+	return headers
+}
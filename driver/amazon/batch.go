@@ -0,0 +1,37 @@
+package amazon
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// maxBatchEntries maximum number of entries accepted by a single Amazon SQS, SNS or EventBridge batch request.
+const maxBatchEntries = 10
+
+// ErrBatchEntryFailed an Amazon service rejected an entry of a batch request.
+var ErrBatchEntryFailed = errors.New("streams: Amazon batch entry failed")
+
+// newBatchEntryID builds the identifier of a batch request entry, which is the position of the message in the
+// streams.BatchResult.
+//
+// Message IDs are not used as they might not be unique within a batch nor follow Amazon identifier constraints.
+func newBatchEntryID(index int) *string {
+	return aws.String(strconv.Itoa(index))
+}
+
+// parseBatchEntryID retrieves the position of a message in the streams.BatchResult from a batch entry identifier.
+func parseBatchEntryID(id *string) (int, bool) {
+	if id == nil {
+		return 0, false
+	}
+	index, err := strconv.Atoi(*id)
+	return index, err == nil
+}
+
+// newBatchEntryError builds an error from the failure details of a batch entry.
+func newBatchEntryError(code, message *string) error {
+	return fmt.Errorf("%w: %s: %s", ErrBatchEntryFailed, aws.ToString(code), aws.ToString(message))
+}
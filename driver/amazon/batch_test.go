@@ -0,0 +1,181 @@
+package amazon_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/driver/amazon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSqsWriter_WriteBatch_Results(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo-stream")
+	stub := newSqsServerStub(*queueUrl)
+	stub.rejected["3"] = true
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	messages := make([]streams.Message, 0, 13)
+	for i := 0; i < 12; i++ {
+		messages = append(messages, streams.Message{ID: strconv.Itoa(i), Stream: "foo-stream"})
+	}
+	// stub holds a single queue, so any other queue fails the whole request
+	messages = append(messages, streams.Message{ID: "12", Stream: "bar-stream"})
+
+	writer := amazon.NewSqsWriter(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	res, err := writer.WriteBatch(context.Background(), messages...)
+	assert.ErrorIs(t, err, amazon.ErrBatchEntryFailed)
+	require.Len(t, res, len(messages))
+	assert.Equal(t, uint32(11), res.Succeeded())
+	assert.ErrorIs(t, res[3].Err, amazon.ErrBatchEntryFailed)
+	assert.Error(t, res[12].Err)
+	assert.Equal(t, []streams.Message{messages[3], messages[12]}, res.FailedMessages())
+	// chunks of 10 entries per queue
+	assert.Equal(t, 2, stub.batchCalls)
+	assert.Len(t, stub.pending, 11)
+}
+
+func TestSqsWriter_WriteBatch_Fifo(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream.fifo")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	messages := make([]streams.Message, 0, 25)
+	for i := 0; i < 25; i++ {
+		messages = append(messages, streams.Message{
+			ID:              strconv.Itoa(i),
+			Stream:          "foo.stream.fifo",
+			PartitionKey:    "foo-key",
+			DeduplicationID: "dedup-" + strconv.Itoa(i),
+		})
+	}
+	writer := amazon.NewSqsWriter(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	res, err := writer.WriteBatch(context.Background(), messages...)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(25), res.Succeeded())
+	assert.Equal(t, 3, stub.batchCalls)
+	// chunks of a queue are sent in order
+	require.Len(t, stub.pending, 25)
+	for i, msg := range stub.pending {
+		out, err := amazon.UnmarshalMessage(&msg.Body)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(i), out.ID)
+		assert.Equal(t, "foo-key", out.PartitionKey)
+		assert.Equal(t, "foo-key", msg.GroupID)
+		assert.Equal(t, "dedup-"+strconv.Itoa(i), msg.DeduplicationID)
+	}
+}
+
+func TestSqsWriter_WriteBatch_Standard(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo-stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	// e.g. a FIFO message dead-lettered into a standard queue
+	message := streams.Message{
+		ID:              "1",
+		Stream:          "foo-stream",
+		PartitionKey:    "foo-key",
+		DeduplicationID: "dedup-1",
+	}
+	writer := amazon.NewSqsWriter(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	require.NoError(t, writer.Write(context.Background(), message))
+	res, err := writer.WriteBatch(context.Background(), message)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), res.Succeeded())
+
+	// standard queues reject message group and deduplication IDs
+	require.Len(t, stub.pending, 2)
+	for _, msg := range stub.pending {
+		assert.Empty(t, msg.GroupID)
+		assert.Empty(t, msg.DeduplicationID)
+		out, err := amazon.UnmarshalMessage(&msg.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "foo-key", out.PartitionKey)
+	}
+}
+
+func TestSqsWriter_WriteBatch_Delay(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo-stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	deliverAt := func(d time.Duration) string {
+		return time.Now().Add(d).UTC().Format(time.RFC3339Nano)
+	}
+	writer := amazon.NewSqsWriter(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	res, err := writer.WriteBatch(context.Background(),
+		streams.Message{ID: "0", Stream: "foo-stream"},
+		streams.Message{ID: "1", Stream: "foo-stream", DeliverAt: deliverAt(time.Second * 90)},
+		streams.Message{ID: "2", Stream: "foo-stream", DeliverAt: deliverAt(time.Hour)})
+	assert.ErrorIs(t, err, amazon.ErrDelayTooLong)
+	assert.Equal(t, uint32(2), res.Succeeded())
+	assert.ErrorIs(t, res[2].Err, amazon.ErrDelayTooLong)
+
+	require.Len(t, stub.pending, 2)
+	assert.Empty(t, stub.pending[0].DelaySeconds)
+	// delays are rounded up to the next second
+	assert.Equal(t, "90", stub.pending[1].DelaySeconds)
+}
+
+func TestSqsWriter_WriteBatch_FifoDelay(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream.fifo")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	// FIFO queues have no per-message delays
+	delayed := streams.Message{ID: "1", Stream: "foo.stream.fifo",
+		DeliverAt: time.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano)}
+	writer := amazon.NewSqsWriter(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	assert.ErrorIs(t, writer.Write(context.Background(), delayed), streams.ErrDelayNotSupported)
+	res, err := writer.WriteBatch(context.Background(), streams.Message{ID: "0", Stream: "foo.stream.fifo"}, delayed)
+	assert.ErrorIs(t, err, streams.ErrDelayNotSupported)
+	assert.Equal(t, uint32(1), res.Succeeded())
+	assert.ErrorIs(t, res[1].Err, streams.ErrDelayNotSupported)
+	require.Len(t, stub.pending, 1)
+	assert.Empty(t, stub.pending[0].DelaySeconds)
+}
+
+type schedulerFunc func(context.Context, streams.Message) error
+
+func (f schedulerFunc) Schedule(ctx context.Context, message streams.Message) error {
+	return f(ctx, message)
+}
+
+func TestWriter_DelayNotSupported(t *testing.T) {
+	deliverAt := time.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano)
+	message := streams.Message{ID: "1", Stream: "foo-stream", DeliverAt: deliverAt}
+	// delayed messages never reach the Amazon service
+	writers := map[string]streams.Writer{
+		"sns":         amazon.NewSnsWriter(nil, defaultLocalAwsAccountID, "us-east-1"),
+		"eventbridge": amazon.NewEventBridgeWriter(nil, defaultLocalAwsAccountID, "us-east-1", "foo-bus"),
+	}
+	for name, writer := range writers {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, writer.Write(context.Background(), message), streams.ErrDelayNotSupported)
+			res, err := writer.WriteBatch(context.Background(), message)
+			assert.ErrorIs(t, err, streams.ErrDelayNotSupported)
+			assert.ErrorIs(t, res[0].Err, streams.ErrDelayNotSupported)
+
+			// delayed messages are handed over to a scheduler instead
+			var scheduled []string
+			hub := streams.NewHub(streams.WithWriter(writer),
+				streams.WithWriterBehaviours(streams.NewSchedulerWriterBehaviour(
+					schedulerFunc(func(_ context.Context, message streams.Message) error {
+						scheduled = append(scheduled, message.ID)
+						return nil
+					}), 0)))
+			assert.NoError(t, hub.WriteRawMessage(context.Background(), message))
+			assert.Equal(t, []string{"1"}, scheduled)
+		})
+	}
+}
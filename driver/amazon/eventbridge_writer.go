@@ -2,7 +2,6 @@ package amazon
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,6 +12,9 @@ import (
 
 // EventBridgeWriter is the Amazon Web Services EventBridge (formerly known as CloudWatch Events) implementation
 // of streams.Writer.
+//
+// Amazon EventBridge has no delayed delivery, so messages with a delivery time (streams.Message DeliverAt) fail with
+// streams.ErrDelayNotSupported.
 type EventBridgeWriter struct {
 	busArn *string
 	client *eventbridge.Client
@@ -29,45 +31,66 @@ func NewEventBridgeWriter(c *eventbridge.Client, accountID, region, busName stri
 }
 
 func (e EventBridgeWriter) Write(ctx context.Context, message streams.Message) error {
-	_, err := e.WriteBatch(ctx, message)
-	return err
+	res, _ := e.WriteBatch(ctx, message)
+	return res[0].Err
 }
 
-func (e EventBridgeWriter) WriteBatch(ctx context.Context, messages ...streams.Message) (uint32, error) {
-	entries := newEventBridgeMessageBatch(e.busArn, messages)
-	o, err := e.client.PutEvents(ctx, &eventbridge.PutEventsInput{
-		Entries: entries,
-	})
-	if err != nil {
-		return 0, err
-	}
-	successfulWrites := uint32(len(messages)) - uint32(o.FailedEntryCount)
-	for _, entry := range o.Entries {
-		if entry.ErrorMessage != nil {
-			return successfulWrites, errors.New(*entry.ErrorMessage)
+// WriteBatch puts the given set of messages into the event bus in chunks of up to 10 entries (Amazon EventBridge
+// limit).
+//
+// Returns the result of each message, thus failed entries might be retried individually.
+func (e EventBridgeWriter) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	entries, indexes := newEventBridgeMessageBatch(e.busArn, res)
+	for start := 0; start < len(entries); start += maxBatchEntries {
+		end := start + maxBatchEntries
+		if end > len(entries) {
+			end = len(entries)
+		}
+		o, err := e.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+			Entries: entries[start:end],
+		})
+		if err != nil {
+			for _, index := range indexes[start:end] {
+				res[index].Err = err
+			}
+			continue
+		}
+		// response entries keep the same order of the request entries
+		for i, entry := range o.Entries {
+			if entry.ErrorCode != nil || entry.ErrorMessage != nil {
+				res[indexes[start+i]].Err = newBatchEntryError(entry.ErrorCode, entry.ErrorMessage)
+			}
 		}
 	}
-	return successfulWrites, nil
+	return res, res.Err()
 }
 
-func newEventBridgeMessageBatch(busArn *string, messages []streams.Message) []types.PutEventsRequestEntry {
-	if len(messages) == 0 {
-		return nil
-	}
-
-	entries := make([]types.PutEventsRequestEntry, 0, len(messages))
-	for _, msg := range messages {
-		rawMsg, err := MarshalMessage(msg)
+// newEventBridgeMessageBatch builds the request entries of the given result messages. Messages failing to be
+// marshaled or having a delivery time are marked as failed.
+//
+// Returns the position in the result of each entry.
+func newEventBridgeMessageBatch(busArn *string, res streams.BatchResult) ([]types.PutEventsRequestEntry, []int) {
+	entries := make([]types.PutEventsRequestEntry, 0, len(res))
+	indexes := make([]int, 0, len(res))
+	for i, item := range res {
+		if item.Message.Delay() > 0 {
+			res[i].Err = streams.ErrDelayNotSupported
+			continue
+		}
+		rawMsg, err := MarshalMessage(item.Message)
 		if err != nil {
-			return nil
+			res[i].Err = err
+			continue
 		}
 		entries = append(entries, types.PutEventsRequestEntry{
 			Detail:       rawMsg,
-			DetailType:   aws.String(msg.Stream),
+			DetailType:   aws.String(item.Message.Stream),
 			EventBusName: busArn,
-			Source:       aws.String(msg.Source),
+			Source:       aws.String(item.Message.Source),
 			Time:         aws.Time(time.Now().UTC()),
 		})
+		indexes = append(indexes, i)
 	}
-	return entries
+	return entries, indexes
 }
@@ -111,5 +111,5 @@ func (s *EventBridgeWriterSuite) TestEventBridgeWriter_WriteBatch() {
 		Subject:              "",
 	}))
 	s.Assert().NoError(err)
-	s.Assert().Equal(uint32(2), out)
+	s.Assert().Equal(uint32(2), out.Succeeded())
 }
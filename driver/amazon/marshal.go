@@ -1,12 +1,17 @@
 package amazon
 
 import (
+	"errors"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/neutrinocorp/streams"
 	"github.com/neutrinocorp/streams/parser"
 )
 
+// ErrEmptyMessageBody the received Amazon message has no body to decode
+var ErrEmptyMessageBody = errors.New("streams: Amazon message body is empty")
+
 // MarshalMessage converts a streams.Message into a JSON string ready to be published to Amazon Simple
 // Notification Service (SNS) and/or Amazon Simple Queue Service (SQS).
 func MarshalMessage(message streams.Message) (*string, error) {
@@ -16,3 +21,15 @@ func MarshalMessage(message streams.Message) (*string, error) {
 	}
 	return aws.String(parser.UnsafeBytesToString(msgJSON)), nil
 }
+
+// UnmarshalMessage converts a JSON string received from Amazon Simple Queue Service (SQS) into a streams.Message.
+//
+// This is the inverse operation of MarshalMessage.
+func UnmarshalMessage(body *string) (streams.Message, error) {
+	if body == nil || *body == "" {
+		return streams.Message{}, ErrEmptyMessageBody
+	}
+	msg := streams.Message{}
+	err := jsoniter.Unmarshal(parser.UnsafeStringToBytes(*body), &msg)
+	return msg, err
+}
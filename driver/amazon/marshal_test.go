@@ -3,6 +3,7 @@ package amazon_test
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/neutrinocorp/streams"
 	"github.com/neutrinocorp/streams/driver/amazon"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +34,15 @@ func TestMarshalSnsMessage(t *testing.T) {
 			Exp: "{\"stream\":\"foo.bar.baz\",\"stream_version\":0,\"id\":\"123\",\"source\":\"org.ncorp.foo\",\"specversion\":\"\",\"type\":\"\",\"data\":\"bG9yZW0gaXBzdW0gZG9sb3Igc2l0IGFtZXQ=\",\"correlation_id\":\"\",\"causation_id\":\"\"}",
 			Err: nil,
 		},
+		{
+			Name: "Headers",
+			In: streams.Message{
+				ID:      "123",
+				Headers: map[string]string{"tenantid": "foo"},
+			},
+			Exp: "{\"stream\":\"\",\"stream_version\":0,\"id\":\"123\",\"source\":\"\",\"specversion\":\"\",\"type\":\"\",\"data\":null,\"correlation_id\":\"\",\"causation_id\":\"\",\"headers\":{\"tenantid\":\"foo\"}}",
+			Err: nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -59,3 +69,44 @@ func BenchmarkMarshalSnsMessage(b *testing.B) {
 		_, _ = amazon.MarshalMessage(inMsg)
 	}
 }
+
+func TestUnmarshalMessage(t *testing.T) {
+	tests := []struct {
+		Name string
+		In   *string
+		Exp  streams.Message
+		Err  error
+	}{
+		{
+			Name: "Nil",
+			In:   nil,
+			Exp:  streams.Message{},
+			Err:  amazon.ErrEmptyMessageBody,
+		},
+		{
+			Name: "Empty",
+			In:   aws.String(""),
+			Exp:  streams.Message{},
+			Err:  amazon.ErrEmptyMessageBody,
+		},
+		{
+			Name: "Populated",
+			In:   aws.String("{\"stream\":\"foo.bar.baz\",\"stream_version\":0,\"id\":\"123\",\"source\":\"org.ncorp.foo\",\"specversion\":\"\",\"type\":\"\",\"data\":\"bG9yZW0gaXBzdW0gZG9sb3Igc2l0IGFtZXQ=\",\"correlation_id\":\"\",\"causation_id\":\"\"}"),
+			Exp: streams.Message{
+				ID:     "123",
+				Source: "org.ncorp.foo",
+				Stream: "foo.bar.baz",
+				Data:   []byte("lorem ipsum dolor sit amet"),
+			},
+			Err: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			exp, err := amazon.UnmarshalMessage(tt.In)
+			assert.Equal(t, tt.Err, err)
+			assert.Equal(t, tt.Exp, exp)
+		})
+	}
+}
@@ -3,9 +3,7 @@ package amazon
 import (
 	"context"
 	"sync"
-	"sync/atomic"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sns/types"
 
 	"github.com/aws/aws-sdk-go-v2/service/sns"
@@ -13,6 +11,11 @@ import (
 )
 
 // SnsWriter is the Amazon Web Services Simple Notification Service (SNS) implementation of streams.Writer.
+//
+// Amazon SNS has no delayed delivery, so messages with a delivery time (streams.Message DeliverAt) fail with
+// streams.ErrDelayNotSupported.
+//
+// Message group (streams.Message PartitionKey) and deduplication IDs are sent only to FIFO topics (FifoSuffix).
 type SnsWriter struct {
 	region, accountID string
 	client            *sns.Client
@@ -30,49 +33,90 @@ func NewSnsWriter(c *sns.Client, accountID, region string) SnsWriter {
 }
 
 func (s SnsWriter) Write(ctx context.Context, message streams.Message) error {
+	if message.Delay() > 0 {
+		return streams.ErrDelayNotSupported
+	}
 	msgSns, err := MarshalMessage(message)
 	if err != nil {
 		return err
 	}
 	_, err = s.client.Publish(ctx, &sns.PublishInput{
-		Message:  msgSns,
-		TopicArn: NewTopic(s.region, s.accountID, message.Stream),
+		Message:           msgSns,
+		TopicArn:          NewTopic(s.region, s.accountID, message.Stream),
+		MessageAttributes: newSnsMessageAttributes(message.Headers),
+		// only FIFO topics accept message group and deduplication IDs
+		MessageGroupId:         newFifoString(message.Stream, message.PartitionKey),
+		MessageDeduplicationId: newFifoString(message.Stream, message.DeduplicationID),
 	})
 	return err
 }
 
-func (s SnsWriter) WriteBatch(ctx context.Context, messages ...streams.Message) (published uint32, err error) {
+// WriteBatch publishes the given set of messages into their topics. Messages are grouped by topic and sent in
+// chunks of up to 10 entries (Amazon SNS limit). Topics are written concurrently while the chunks of a topic are sent
+// sequentially to preserve ordering (FIFO topics).
+//
+// Returns the result of each message, thus failed entries might be retried individually.
+func (s SnsWriter) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
 	// Map each stream from `messages` to a batch.
 	//
 	// Therefore, publish each batch to its requested stream.
 	batchBuffer := map[string][]types.PublishBatchRequestEntry{}
-	for _, msg := range messages {
-		rawJSON, errScoped := MarshalMessage(msg)
-		if errScoped != nil {
-			err = errScoped
-			return
+	for i, msg := range messages {
+		if msg.Delay() > 0 {
+			res[i].Err = streams.ErrDelayNotSupported
+			continue
+		}
+		rawJSON, err := MarshalMessage(msg)
+		if err != nil {
+			res[i].Err = err
+			continue
 		}
 		batchBuffer[msg.Stream] = append(batchBuffer[msg.Stream], types.PublishBatchRequestEntry{
-			Id:      aws.String(msg.ID),
-			Message: rawJSON,
+			Id:                     newBatchEntryID(i),
+			Message:                rawJSON,
+			MessageAttributes:      newSnsMessageAttributes(msg.Headers),
+			MessageGroupId:         newFifoString(msg.Stream, msg.PartitionKey),
+			MessageDeduplicationId: newFifoString(msg.Stream, msg.DeduplicationID),
 		})
 	}
 
 	wg := sync.WaitGroup{}
 	wg.Add(len(batchBuffer))
 	for stream, batch := range batchBuffer {
-		go func(stream string, messages []types.PublishBatchRequestEntry) {
+		// every goroutine writes into a different set of result positions
+		go func(stream string, batch []types.PublishBatchRequestEntry) {
 			defer wg.Done()
-			_, err = s.client.PublishBatch(ctx, &sns.PublishBatchInput{
-				PublishBatchRequestEntries: messages,
-				TopicArn:                   NewTopic(s.region, s.accountID, stream),
-			})
-			if err != nil {
-				return
+			for start := 0; start < len(batch); start += maxBatchEntries {
+				end := start + maxBatchEntries
+				if end > len(batch) {
+					end = len(batch)
+				}
+				s.publishBatch(ctx, stream, batch[start:end], res)
 			}
-			atomic.AddUint32(&published, 1)
 		}(stream, batch)
 	}
 	wg.Wait()
-	return
+	return res, res.Err()
+}
+
+// publishBatch publishes the given entries to the topic of the given stream, writing the failures into res.
+func (s SnsWriter) publishBatch(ctx context.Context, stream string, entries []types.PublishBatchRequestEntry,
+	res streams.BatchResult) {
+	out, err := s.client.PublishBatch(ctx, &sns.PublishBatchInput{
+		PublishBatchRequestEntries: entries,
+		TopicArn:                   NewTopic(s.region, s.accountID, stream),
+	})
+	if err != nil {
+		for _, entry := range entries {
+			index, _ := parseBatchEntryID(entry.Id)
+			res[index].Err = err
+		}
+		return
+	}
+	for _, entry := range out.Failed {
+		if index, ok := parseBatchEntryID(entry.Id); ok && index < len(res) {
+			res[index].Err = newBatchEntryError(entry.Code, entry.Message)
+		}
+	}
 }
@@ -143,5 +143,5 @@ func (s *SnsWriterSuite) TestSnsWriter_WriteBatch() {
 		Subject:              "",
 	}))
 	s.Assert().NoError(err)
-	s.Assert().Equal(uint32(2), out)
+	s.Assert().Equal(uint32(2), out.Succeeded())
 }
@@ -2,15 +2,364 @@ package amazon
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/neutrinocorp/streams"
 )
 
+const (
+	// sqsMaxNumberOfMessages maximum number of messages Amazon SQS returns from a single ReceiveMessage call.
+	sqsMaxNumberOfMessages = 10
+	// sqsMaxWaitTime maximum long polling duration allowed by Amazon SQS.
+	sqsMaxWaitTime = time.Second * 20
+	// sqsAckTimeout duration for message deletion calls, detached from the stream-reading job context so
+	// successfully processed messages get acknowledged even if the job is shutting down.
+	sqsAckTimeout = time.Second * 5
+	// sqsAllMessageAttributes requests every message attribute from Amazon SQS.
+	sqsAllMessageAttributes = "All"
+	// sqsMaxVisibilityTimeout maximum visibility timeout allowed by Amazon SQS.
+	sqsMaxVisibilityTimeout = time.Hour * 12
+)
+
+// ErrInvalidQueueUrl the Amazon SQS queue url could not be built from the given region, account and stream.
+var ErrInvalidQueueUrl = errors.New("streams: Invalid Amazon SQS queue url")
+
+// SqsReaderConfig Amazon Simple Queue Service (SQS) stream-reading job configuration.
+//
+// Might be set per ReaderNode using streams.WithProviderConfiguration option.
+type SqsReaderConfig struct {
+	// WaitTime long polling duration of each ReceiveMessage call (maximum of 20 seconds).
+	WaitTime time.Duration
+	// VisibilityTimeout duration a received message stays hidden from other consumers. If greater than zero,
+	// the reader will extend the visibility of a message periodically while its handler is running.
+	VisibilityTimeout time.Duration
+	// MaxNumberOfMessages maximum number of messages to receive on each ReceiveMessage call (maximum of 10).
+	// Capped by the ReaderTask's MaxHandlerPoolSize.
+	MaxNumberOfMessages int32
+	// PollingErrorInterval duration to wait before polling again after a failed ReceiveMessage call.
+	PollingErrorInterval time.Duration
+}
+
+// DefaultSqsReaderConfig default configuration of SqsReader stream-reading jobs.
+var DefaultSqsReaderConfig = SqsReaderConfig{
+	WaitTime:             sqsMaxWaitTime,
+	VisibilityTimeout:    time.Second * 30,
+	MaxNumberOfMessages:  sqsMaxNumberOfMessages,
+	PollingErrorInterval: time.Second,
+}
+
+// SqsReader is the Amazon Web Services Simple Queue Service (SQS) implementation of streams.Reader.
+//
+// Uses long polling to receive messages. A message is deleted from the queue only if its handler succeeded; otherwise,
+// it is left in the queue to be redelivered once its visibility timeout expires.
+//
+// Handlers might settle messages explicitly using their streams.Acknowledger: Ack and Term delete the message from the
+// queue while Nack changes its visibility timeout, so it is redelivered after the requested delay (maximum of 12
+// hours). Messages not settled before their visibility timeout expires are redelivered.
+//
+// The zero value is not ready to use, allocate instances using NewSqsReader.
 type SqsReader struct {
+	region, accountID string
+	client            *sqs.Client
+	config            SqsReaderConfig
+
+	jobs      *sync.WaitGroup
+	done      chan struct{}
+	closeOnce *sync.Once
 }
 
-var _ streams.Reader = SqsReader{}
+var (
+	_ streams.Reader     = SqsReader{}
+	_ streams.Shutdowner = SqsReader{}
+)
+
+// NewSqsReader allocates a new SqsReader ready to be used.
+func NewSqsReader(c *sqs.Client, accountID, region string) SqsReader {
+	return SqsReader{
+		region:    region,
+		accountID: accountID,
+		client:    c,
+		config:    DefaultSqsReaderConfig,
+		jobs:      &sync.WaitGroup{},
+		done:      make(chan struct{}),
+		closeOnce: &sync.Once{},
+	}
+}
 
-func (s SqsReader) ExecuteTask(_ context.Context, _ streams.ReaderTask) error {
+// ExecuteTask starts a background long polling job for the queue named after the task stream.
+//
+// The job stops once the given context is canceled.
+func (s SqsReader) ExecuteTask(ctx context.Context, task streams.ReaderTask) error {
+	queueUrl := NewQueueUrl(s.region, s.accountID, task.Stream)
+	if queueUrl == nil {
+		return ErrInvalidQueueUrl
+	} else if task.HandlerFunc == nil {
+		return streams.ErrMissingReaderHandler
+	}
+
+	cfg := s.config
+	if scopedCfg, ok := task.Configuration.(SqsReaderConfig); ok {
+		cfg = scopedCfg
+	}
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		s.poll(ctx, queueUrl, task, cfg)
+	}()
 	return nil
 }
+
+// Shutdown stops every stream-reading job from receiving new messages and waits for in-flight handlers to finish.
+//
+// Handlers keep their context alive while the reader is shutting down, so messages being processed are not lost.
+func (s SqsReader) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	finished := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// poll receives messages from the given queue until the context is canceled or the reader is shut down, scheduling
+// a handler for each message without exceeding the task's MaxHandlerPoolSize.
+func (s SqsReader) poll(ctx context.Context, queueUrl *string, task streams.ReaderTask, cfg SqsReaderConfig) {
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-pollCtx.Done():
+		}
+	}()
+
+	poolSize := task.MaxHandlerPoolSize
+	if poolSize <= 0 {
+		poolSize = streams.DefaultMaxHandlerPoolSize
+	}
+	batchSize := cfg.MaxNumberOfMessages
+	if batchSize <= 0 || batchSize > sqsMaxNumberOfMessages {
+		batchSize = sqsMaxNumberOfMessages
+	}
+	if task.BatchSize > 0 && int32(task.BatchSize) < batchSize {
+		// messages are still handled one by one; receiving up to a batch per call lets the node's batch handler gather
+		// the messages of a call into a single batch
+		batchSize = int32(task.BatchSize)
+	}
+	waitTime := cfg.WaitTime
+	if waitTime > sqsMaxWaitTime {
+		waitTime = sqsMaxWaitTime
+	}
+
+	sem := make(chan struct{}, poolSize)
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for {
+		acquired := acquireSlots(pollCtx, sem, int(batchSize))
+		if acquired == 0 {
+			return
+		}
+		out, err := s.client.ReceiveMessage(pollCtx, &sqs.ReceiveMessageInput{
+			QueueUrl:              queueUrl,
+			MaxNumberOfMessages:   int32(acquired),
+			VisibilityTimeout:     int32(cfg.VisibilityTimeout.Seconds()),
+			WaitTimeSeconds:       int32(waitTime.Seconds()),
+			MessageAttributeNames: []string{sqsAllMessageAttributes},
+		})
+		if err != nil {
+			releaseSlots(sem, acquired)
+			if !sleepContext(pollCtx, cfg.PollingErrorInterval) {
+				return
+			}
+			continue
+		}
+
+		releaseSlots(sem, acquired-len(out.Messages))
+		wg.Add(len(out.Messages))
+		for _, msg := range out.Messages {
+			go func(msg types.Message) {
+				defer wg.Done()
+				defer releaseSlots(sem, 1)
+				s.handleMessage(ctx, queueUrl, task, cfg, msg)
+			}(msg)
+		}
+	}
+}
+
+// handleMessage executes the task handler with the decoded message, deleting it from the queue if the handler succeeded.
+//
+// Messages failing to be decoded are left in the queue, so the queue's redrive policy (if any) moves them
+// into a dead-letter queue.
+func (s SqsReader) handleMessage(ctx context.Context, queueUrl *string, task streams.ReaderTask, cfg SqsReaderConfig,
+	msg types.Message) {
+	message, err := UnmarshalMessage(msg.Body)
+	if err != nil {
+		return
+	}
+	message.Headers = mergeSqsMessageAttributes(message.Headers, msg.MessageAttributes)
+
+	var (
+		scopedCtx context.Context
+		cancel    context.CancelFunc
+	)
+	if task.Timeout > 0 {
+		scopedCtx, cancel = context.WithTimeout(ctx, task.Timeout)
+	} else {
+		scopedCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	stopExtension := s.extendVisibility(scopedCtx, queueUrl, msg.ReceiptHandle, cfg.VisibilityTimeout)
+	ack := streams.NewOnceAcknowledger(sqsAcknowledger{
+		client:        s.client,
+		queueUrl:      queueUrl,
+		receiptHandle: msg.ReceiptHandle,
+		// settled messages must not be hidden again
+		stopExtension: stopExtension,
+	})
+	message.Acknowledger = ack
+	err = task.HandlerFunc(streams.ContextWithAcknowledger(scopedCtx, ack), message)
+	stopExtension()
+	if err != nil || task.ManualAck || ack.Settled() {
+		return
+	}
+
+	ackCtx, cancelAck := context.WithTimeout(context.Background(), sqsAckTimeout)
+	defer cancelAck()
+	_ = ack.Ack(ackCtx)
+}
+
+// sqsAcknowledger is the Amazon SQS implementation of streams.Acknowledger.
+type sqsAcknowledger struct {
+	client                  *sqs.Client
+	queueUrl, receiptHandle *string
+	stopExtension           func()
+}
+
+var _ streams.Acknowledger = sqsAcknowledger{}
+
+// Ack deletes the message from the queue.
+func (a sqsAcknowledger) Ack(ctx context.Context) error {
+	a.stopExtension()
+	_, err := a.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      a.queueUrl,
+		ReceiptHandle: a.receiptHandle,
+	})
+	return err
+}
+
+// Nack changes the visibility timeout of the message, so it is redelivered once the given delay (rounded up to the
+// next second) has elapsed.
+func (a sqsAcknowledger) Nack(ctx context.Context, requeueAfter time.Duration) error {
+	a.stopExtension()
+	if requeueAfter > sqsMaxVisibilityTimeout {
+		requeueAfter = sqsMaxVisibilityTimeout
+	} else if requeueAfter < 0 {
+		requeueAfter = 0
+	}
+	_, err := a.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          a.queueUrl,
+		ReceiptHandle:     a.receiptHandle,
+		VisibilityTimeout: int32((requeueAfter + time.Second - 1) / time.Second),
+	})
+	return err
+}
+
+// Term deletes the message from the queue, so the queue's redrive policy (if any) is skipped.
+func (a sqsAcknowledger) Term(ctx context.Context) error {
+	return a.Ack(ctx)
+}
+
+// extendVisibility keeps a message hidden from other consumers while its handler is running by resetting its
+// visibility timeout every half of the given timeout.
+//
+// Returns a function to stop the extension job, which might be called several times.
+func (s SqsReader) extendVisibility(ctx context.Context, queueUrl, receiptHandle *string,
+	timeout time.Duration) func() {
+	if timeout < time.Second*2 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(timeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = s.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+					QueueUrl:          queueUrl,
+					ReceiptHandle:     receiptHandle,
+					VisibilityTimeout: int32(timeout.Seconds()),
+				})
+			}
+		}
+	}()
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// acquireSlots blocks until at least one slot from the given semaphore is available, then acquires up to n slots
+// without blocking.
+//
+// Returns zero if the given context was canceled.
+func acquireSlots(ctx context.Context, sem chan struct{}, n int) int {
+	select {
+	case <-ctx.Done():
+		return 0
+	case sem <- struct{}{}:
+	}
+	acquired := 1
+	for acquired < n {
+		select {
+		case sem <- struct{}{}:
+			acquired++
+		default:
+			return acquired
+		}
+	}
+	return acquired
+}
+
+func releaseSlots(sem chan struct{}, n int) {
+	for i := 0; i < n; i++ {
+		<-sem
+	}
+}
+
+// sleepContext pauses the current goroutine for the given duration.
+//
+// Returns false if the given context was canceled before the duration elapsed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
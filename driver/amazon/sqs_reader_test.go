@@ -0,0 +1,475 @@
+package amazon_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/driver/amazon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqsServerStub is a minimal stand-in of the Amazon SQS query API, holding a single queue.
+type sqsServerStub struct {
+	mu         sync.Mutex
+	queueUrl   string
+	pending    []sqsStubMessage
+	inFlight   map[string]sqsStubMessage
+	deleted    []string
+	extensions int
+	// visibility holds the VisibilityTimeout of every ChangeMessageVisibility call
+	visibility  []string
+	receiveCall int
+	// receiveLimits holds the MaxNumberOfMessages of every ReceiveMessage call
+	receiveLimits []string
+	batchCalls    int
+	// rejected holds the streams.Message IDs to be reported as failed entries by SendMessageBatch
+	rejected map[string]bool
+}
+
+type sqsStubMessage struct {
+	MessageId     string                    `xml:"MessageId"`
+	ReceiptHandle string                    `xml:"ReceiptHandle"`
+	Body          string                    `xml:"Body"`
+	Attributes    []sqsStubMessageAttribute `xml:"MessageAttribute"`
+	// send fields, not sent back by the stub
+	GroupID         string `xml:"-"`
+	DeduplicationID string `xml:"-"`
+	DelaySeconds    string `xml:"-"`
+}
+
+type sqsStubMessageAttribute struct {
+	Name        string `xml:"Name"`
+	DataType    string `xml:"Value>DataType"`
+	StringValue string `xml:"Value>StringValue"`
+}
+
+type sqsStubReceiveResponse struct {
+	XMLName  xml.Name         `xml:"ReceiveMessageResponse"`
+	Messages []sqsStubMessage `xml:"ReceiveMessageResult>Message"`
+}
+
+type sqsStubBatchEntry struct {
+	Id        string `xml:"Id"`
+	MessageId string `xml:"MessageId"`
+}
+
+type sqsStubBatchError struct {
+	Id          string `xml:"Id"`
+	Code        string `xml:"Code"`
+	Message     string `xml:"Message"`
+	SenderFault bool   `xml:"SenderFault"`
+}
+
+type sqsStubSendBatchResponse struct {
+	XMLName    xml.Name            `xml:"SendMessageBatchResponse"`
+	Successful []sqsStubBatchEntry `xml:"SendMessageBatchResult>SendMessageBatchResultEntry"`
+	Failed     []sqsStubBatchError `xml:"SendMessageBatchResult>BatchResultErrorEntry"`
+}
+
+func newSqsServerStub(queueUrl string) *sqsServerStub {
+	return &sqsServerStub{
+		queueUrl: queueUrl,
+		inFlight: map[string]sqsStubMessage{},
+		rejected: map[string]bool{},
+	}
+}
+
+func (s *sqsServerStub) push(t *testing.T, message streams.Message, attributes ...sqsStubMessageAttribute) {
+	body, err := amazon.MarshalMessage(message)
+	require.NoError(t, err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, sqsStubMessage{
+		Body:       *body,
+		Attributes: attributes,
+	})
+}
+
+func (s *sqsServerStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("QueueUrl") != s.queueUrl {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Form.Get("Action") {
+	case "ReceiveMessage":
+		s.receiveCall++
+		s.receiveLimits = append(s.receiveLimits, r.Form.Get("MaxNumberOfMessages"))
+		limit, _ := strconv.Atoi(r.Form.Get("MaxNumberOfMessages"))
+		res := sqsStubReceiveResponse{}
+		for len(s.pending) > 0 && len(res.Messages) < limit {
+			handle := "handle-" + strconv.Itoa(s.receiveCall) + "-" + strconv.Itoa(len(res.Messages))
+			msg := s.pending[0]
+			msg.MessageId = handle
+			msg.ReceiptHandle = handle
+			s.inFlight[handle] = msg
+			res.Messages = append(res.Messages, msg)
+			s.pending = s.pending[1:]
+		}
+		if len(res.Messages) == 0 {
+			// mimic a short long polling wait
+			time.Sleep(time.Millisecond * 10)
+		}
+		_ = xml.NewEncoder(w).Encode(res)
+	case "DeleteMessage":
+		handle := r.Form.Get("ReceiptHandle")
+		delete(s.inFlight, handle)
+		s.deleted = append(s.deleted, handle)
+		_, _ = w.Write([]byte("<DeleteMessageResponse></DeleteMessageResponse>"))
+	case "SendMessage":
+		s.pending = append(s.pending, sqsStubMessage{
+			Body:            r.Form.Get("MessageBody"),
+			GroupID:         r.Form.Get("MessageGroupId"),
+			DeduplicationID: r.Form.Get("MessageDeduplicationId"),
+			DelaySeconds:    r.Form.Get("DelaySeconds"),
+		})
+		_, _ = w.Write([]byte("<SendMessageResponse><SendMessageResult></SendMessageResult></SendMessageResponse>"))
+	case "SendMessageBatch":
+		s.batchCalls++
+		res := sqsStubSendBatchResponse{}
+		for i := 1; r.Form.Get("SendMessageBatchRequestEntry."+strconv.Itoa(i)+".Id") != ""; i++ {
+			prefix := "SendMessageBatchRequestEntry." + strconv.Itoa(i) + "."
+			id, body := r.Form.Get(prefix+"Id"), r.Form.Get(prefix+"MessageBody")
+			msg, _ := amazon.UnmarshalMessage(&body)
+			if s.rejected[msg.ID] {
+				res.Failed = append(res.Failed, sqsStubBatchError{Id: id, Code: "InvalidMessageContents",
+					Message: "rejected", SenderFault: true})
+				continue
+			}
+			s.pending = append(s.pending, sqsStubMessage{
+				Body:            body,
+				GroupID:         r.Form.Get(prefix + "MessageGroupId"),
+				DeduplicationID: r.Form.Get(prefix + "MessageDeduplicationId"),
+				DelaySeconds:    r.Form.Get(prefix + "DelaySeconds"),
+			})
+			res.Successful = append(res.Successful, sqsStubBatchEntry{Id: id, MessageId: msg.ID})
+		}
+		_ = xml.NewEncoder(w).Encode(res)
+	case "ChangeMessageVisibility":
+		s.extensions++
+		timeout := r.Form.Get("VisibilityTimeout")
+		s.visibility = append(s.visibility, timeout)
+		if msg, ok := s.inFlight[r.Form.Get("ReceiptHandle")]; ok && timeout == "0" {
+			// message becomes visible at once
+			delete(s.inFlight, r.Form.Get("ReceiptHandle"))
+			s.pending = append(s.pending, msg)
+		}
+		_, _ = w.Write([]byte("<ChangeMessageVisibilityResponse></ChangeMessageVisibilityResponse>"))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (s *sqsServerStub) stats() (inFlight, deleted, extensions int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.inFlight), len(s.deleted), s.extensions
+}
+
+func newSqsStubClient(url string) *sqs.Client {
+	return sqs.New(sqs.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: sqs.EndpointResolverFromURL(url),
+	})
+}
+
+func TestSqsReader_ExecuteTask(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	reader := amazon.NewSqsReader(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	err := reader.ExecuteTask(context.Background(), streams.ReaderTask{})
+	assert.ErrorIs(t, err, amazon.ErrInvalidQueueUrl)
+	err = reader.ExecuteTask(context.Background(), streams.ReaderTask{Stream: "foo.stream"})
+	assert.ErrorIs(t, err, streams.ErrMissingReaderHandler)
+
+	stub.push(t, streams.Message{ID: "1", Stream: "foo.stream", Data: []byte("foo")})
+	stub.push(t, streams.Message{ID: "2", Stream: "foo.stream", Data: []byte("bar")})
+	stub.push(t, streams.Message{ID: "3", Stream: "foo.stream", Data: []byte("baz")})
+
+	mu := sync.Mutex{}
+	received := map[string]string{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = reader.ExecuteTask(ctx, streams.ReaderTask{
+		Stream: "foo.stream",
+		HandlerFunc: func(_ context.Context, message streams.Message) error {
+			mu.Lock()
+			received[message.ID] = string(message.Data)
+			mu.Unlock()
+			if message.ID == "2" {
+				return errors.New("generic error")
+			}
+			return nil
+		},
+		Timeout:            time.Second,
+		MaxHandlerPoolSize: 2,
+		Configuration: amazon.SqsReaderConfig{
+			WaitTime:             time.Second,
+			MaxNumberOfMessages:  10,
+			PollingErrorInterval: time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, deleted, _ := stub.stats()
+		return deleted == 2
+	}, time.Second, time.Millisecond*10)
+	inFlight, _, _ := stub.stats()
+	// failed message is left in the queue for redelivery
+	assert.Equal(t, 1, inFlight)
+	mu.Lock()
+	assert.Equal(t, map[string]string{"1": "foo", "2": "bar", "3": "baz"}, received)
+	mu.Unlock()
+}
+
+func TestSqsReader_ExecuteTask_VisibilityExtension(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	stub.push(t, streams.Message{ID: "1", Stream: "foo.stream"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := amazon.NewSqsReader(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	err := reader.ExecuteTask(ctx, streams.ReaderTask{
+		Stream: "foo.stream",
+		HandlerFunc: func(_ context.Context, _ streams.Message) error {
+			time.Sleep(time.Millisecond * 1200)
+			return nil
+		},
+		Configuration: amazon.SqsReaderConfig{
+			WaitTime:          time.Second,
+			VisibilityTimeout: time.Second * 2,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, deleted, _ := stub.stats()
+		return deleted == 1
+	}, time.Second*3, time.Millisecond*50)
+	_, _, extensions := stub.stats()
+	assert.Equal(t, 1, extensions)
+}
+
+func TestSqsReader_Shutdown(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	stub.push(t, streams.Message{ID: "1", Stream: "foo.stream"})
+
+	handlerStarted := make(chan struct{})
+	reader := amazon.NewSqsReader(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	err := reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream: "foo.stream",
+		HandlerFunc: func(ctx context.Context, _ streams.Message) error {
+			close(handlerStarted)
+			time.Sleep(time.Millisecond * 50)
+			return ctx.Err()
+		},
+		Configuration: amazon.SqsReaderConfig{
+			WaitTime: time.Second,
+		},
+	})
+	require.NoError(t, err)
+	<-handlerStarted
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, reader.Shutdown(ctx), context.DeadlineExceeded)
+	// in-flight handler finishes with a live context, so its message gets deleted
+	assert.NoError(t, reader.Shutdown(context.Background()))
+	_, deleted, _ := stub.stats()
+	assert.Equal(t, 1, deleted)
+}
+
+func TestSqsReader_ExecuteTask_Headers(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	stub.push(t, streams.Message{
+		ID:      "1",
+		Stream:  "foo.stream",
+		Headers: map[string]string{"tenantid": "foo"},
+	}, sqsStubMessageAttribute{
+		Name:        "tenantid",
+		DataType:    "String",
+		StringValue: "bar",
+	}, sqsStubMessageAttribute{
+		Name:        "traceparent",
+		DataType:    "String",
+		StringValue: "00-abc-def-01",
+	}, sqsStubMessageAttribute{
+		Name:     "binaryattr",
+		DataType: "Binary",
+	})
+
+	headers := make(chan map[string]string, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := amazon.NewSqsReader(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	err := reader.ExecuteTask(ctx, streams.ReaderTask{
+		Stream: "foo.stream",
+		HandlerFunc: func(_ context.Context, message streams.Message) error {
+			headers <- message.Headers
+			return nil
+		},
+		Configuration: amazon.SqsReaderConfig{
+			WaitTime: time.Second,
+		},
+	})
+	require.NoError(t, err)
+	// headers carried within the message body take precedence over message attributes
+	assert.Equal(t, map[string]string{
+		"tenantid":    "foo",
+		"traceparent": "00-abc-def-01",
+	}, <-headers)
+}
+
+func TestSqsReader_ExecuteTask_Acknowledger(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	stub.push(t, streams.Message{ID: "1", Stream: "foo.stream"})
+
+	deliveries := make(chan int, 2)
+	attempts := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := amazon.NewSqsReader(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	err := reader.ExecuteTask(ctx, streams.ReaderTask{
+		Stream: "foo.stream",
+		HandlerFunc: func(ctx context.Context, message streams.Message) error {
+			attempts++
+			deliveries <- attempts
+			ack, ok := streams.AcknowledgerFromContext(ctx)
+			require.True(t, ok)
+			if attempts == 1 {
+				// redelivered at once, even if the handler succeeded
+				return ack.Nack(ctx, 0)
+			}
+			return nil
+		},
+		Configuration: amazon.SqsReaderConfig{
+			WaitTime: time.Second,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, <-deliveries)
+	assert.Equal(t, 2, <-deliveries)
+	assert.Eventually(t, func() bool {
+		_, deleted, _ := stub.stats()
+		return deleted == 1
+	}, time.Second, time.Millisecond*10)
+	stub.mu.Lock()
+	assert.Equal(t, []string{"0"}, stub.visibility)
+	stub.mu.Unlock()
+}
+
+func TestSqsReader_ExecuteTask_ManualAck(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	stub.push(t, streams.Message{ID: "1", Stream: "foo.stream"})
+	stub.push(t, streams.Message{ID: "2", Stream: "foo.stream"})
+
+	received := make(chan streams.Message, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := amazon.NewSqsReader(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	err := reader.ExecuteTask(ctx, streams.ReaderTask{
+		Stream: "foo.stream",
+		HandlerFunc: func(_ context.Context, message streams.Message) error {
+			received <- message
+			return nil
+		},
+		ManualAck: true,
+		Configuration: amazon.SqsReaderConfig{
+			WaitTime: time.Second,
+		},
+	})
+	require.NoError(t, err)
+
+	first, second := <-received, <-received
+	time.Sleep(time.Millisecond * 50)
+	inFlight, deleted, _ := stub.stats()
+	// messages are not settled by the reader
+	assert.Equal(t, 2, inFlight)
+	assert.Zero(t, deleted)
+
+	// deferred settlement
+	require.NoError(t, first.Acknowledger.Ack(context.Background()))
+	require.NoError(t, second.Acknowledger.Nack(context.Background(), time.Millisecond*1500))
+	assert.ErrorIs(t, second.Acknowledger.Term(context.Background()), streams.ErrMessageSettled)
+	inFlight, deleted, _ = stub.stats()
+	assert.Equal(t, 1, inFlight)
+	assert.Equal(t, 1, deleted)
+	stub.mu.Lock()
+	assert.Equal(t, []string{"2"}, stub.visibility)
+	stub.mu.Unlock()
+}
+
+func TestSqsReader_ExecuteTask_Batch(t *testing.T) {
+	queueUrl := amazon.NewQueueUrl("us-east-1", defaultLocalAwsAccountID, "foo.stream")
+	stub := newSqsServerStub(*queueUrl)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	for i := 0; i < 5; i++ {
+		stub.push(t, streams.Message{ID: strconv.Itoa(i), Stream: "foo.stream"})
+	}
+
+	received := make(chan streams.Message, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := amazon.NewSqsReader(newSqsStubClient(srv.URL), defaultLocalAwsAccountID, "us-east-1")
+	err := reader.ExecuteTask(ctx, streams.ReaderTask{
+		Stream: "foo.stream",
+		HandlerFunc: func(_ context.Context, message streams.Message) error {
+			received <- message
+			return nil
+		},
+		MaxHandlerPoolSize: 3,
+		BatchSize:          3,
+	})
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		<-received
+	}
+
+	// each receive call feeds up to a whole batch
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	require.NotEmpty(t, stub.receiveLimits)
+	assert.Equal(t, "3", stub.receiveLimits[0])
+	for _, limit := range stub.receiveLimits {
+		assert.LessOrEqual(t, limit, "3")
+	}
+}
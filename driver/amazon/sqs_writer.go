@@ -2,17 +2,31 @@ package amazon
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"sync"
-	"sync/atomic"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/neutrinocorp/streams"
 )
 
+// SqsMaxDelay longest delivery delay supported by Amazon SQS. Use streams.NewSchedulerWriterBehaviour to hand
+// messages with longer delays over to a streams.Scheduler.
+const SqsMaxDelay = time.Minute * 15
+
+// ErrDelayTooLong the message delivery time exceeds the longest delay supported by Amazon SQS.
+var ErrDelayTooLong = errors.New("streams: Message delay exceeds Amazon SQS maximum delay")
+
 // SqsWriter is the Amazon Web Services Simple Queue Service (SQS) implementation of streams.Writer.
+//
+// Messages with a delivery time (streams.Message DeliverAt) are delayed using SQS DelaySeconds, rounded up to the
+// next second. FIFO queues (FifoSuffix) have no per-message delays, so delayed messages written into them fail with
+// streams.ErrDelayNotSupported.
+//
+// Message group (streams.Message PartitionKey) and deduplication IDs are sent only to FIFO queues (FifoSuffix).
 type SqsWriter struct {
 	region, accountID string
 	client            *sqs.Client
@@ -30,49 +44,106 @@ func NewSqsWriter(c *sqs.Client, accountID, region string) SqsWriter {
 }
 
 func (s SqsWriter) Write(ctx context.Context, message streams.Message) error {
+	delay, err := newSqsDelaySeconds(message)
+	if err != nil {
+		return err
+	}
 	rawJSON, err := MarshalMessage(message)
 	if err != nil {
 		return err
 	}
 	_, err = s.client.SendMessage(ctx, &sqs.SendMessageInput{
-		MessageBody: rawJSON,
-		QueueUrl:    NewQueueUrl(s.region, s.accountID, message.Stream),
+		DelaySeconds:      delay,
+		MessageBody:       rawJSON,
+		QueueUrl:          NewQueueUrl(s.region, s.accountID, message.Stream),
+		MessageAttributes: newSqsMessageAttributes(message.Headers),
+		// only FIFO queues accept message group and deduplication IDs
+		MessageGroupId:         newFifoString(message.Stream, message.PartitionKey),
+		MessageDeduplicationId: newFifoString(message.Stream, message.DeduplicationID),
 	})
 	return err
 }
 
-func (s SqsWriter) WriteBatch(ctx context.Context, messages ...streams.Message) (published uint32, err error) {
+// WriteBatch publishes the given set of messages into their queues. Messages are grouped by queue and sent in
+// chunks of up to 10 entries (Amazon SQS limit). Queues are written concurrently while the chunks of a queue are sent
+// sequentially to preserve ordering (FIFO queues).
+//
+// Returns the result of each message, thus failed entries might be retried individually.
+func (s SqsWriter) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
 	// Map each stream from `messages` to a batch.
 	//
 	// Therefore, publish each batch to its requested stream.
 	batchBuffer := map[string][]types.SendMessageBatchRequestEntry{}
-	for _, msg := range messages {
-		rawJSON, errScoped := MarshalMessage(msg)
-		if errScoped != nil {
-			err = errScoped
-			return
+	for i, msg := range messages {
+		delay, err := newSqsDelaySeconds(msg)
+		if err != nil {
+			res[i].Err = err
+			continue
+		}
+		rawJSON, err := MarshalMessage(msg)
+		if err != nil {
+			res[i].Err = err
+			continue
 		}
 		batchBuffer[msg.Stream] = append(batchBuffer[msg.Stream], types.SendMessageBatchRequestEntry{
-			Id:          aws.String(msg.ID),
-			MessageBody: rawJSON,
+			Id:                     newBatchEntryID(i),
+			DelaySeconds:           delay,
+			MessageBody:            rawJSON,
+			MessageAttributes:      newSqsMessageAttributes(msg.Headers),
+			MessageGroupId:         newFifoString(msg.Stream, msg.PartitionKey),
+			MessageDeduplicationId: newFifoString(msg.Stream, msg.DeduplicationID),
 		})
 	}
 
 	wg := sync.WaitGroup{}
 	wg.Add(len(batchBuffer))
 	for stream, batch := range batchBuffer {
-		go func(stream string, messages []types.SendMessageBatchRequestEntry) {
+		// every goroutine writes into a different set of result positions
+		go func(stream string, batch []types.SendMessageBatchRequestEntry) {
 			defer wg.Done()
-			_, err = s.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
-				Entries:  messages,
-				QueueUrl: NewQueueUrl(s.region, s.accountID, stream),
-			})
-			if err != nil {
-				return
+			for start := 0; start < len(batch); start += maxBatchEntries {
+				end := start + maxBatchEntries
+				if end > len(batch) {
+					end = len(batch)
+				}
+				s.sendBatch(ctx, stream, batch[start:end], res)
 			}
-			atomic.AddUint32(&published, 1)
 		}(stream, batch)
 	}
 	wg.Wait()
-	return
+	return res, res.Err()
+}
+
+// sendBatch sends the given entries to the queue of the given stream, writing the failures into res.
+func (s SqsWriter) sendBatch(ctx context.Context, stream string, entries []types.SendMessageBatchRequestEntry,
+	res streams.BatchResult) {
+	out, err := s.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		Entries:  entries,
+		QueueUrl: NewQueueUrl(s.region, s.accountID, stream),
+	})
+	if err != nil {
+		for _, entry := range entries {
+			index, _ := parseBatchEntryID(entry.Id)
+			res[index].Err = err
+		}
+		return
+	}
+	for _, entry := range out.Failed {
+		if index, ok := parseBatchEntryID(entry.Id); ok && index < len(res) {
+			res[index].Err = newBatchEntryError(entry.Code, entry.Message)
+		}
+	}
+}
+
+// newSqsDelaySeconds retrieves the DelaySeconds of the given message. Returns ErrDelayTooLong if the delay exceeds
+// SqsMaxDelay, or streams.ErrDelayNotSupported if the message is delayed and its stream is a FIFO queue.
+func newSqsDelaySeconds(message streams.Message) (int32, error) {
+	delay := message.Delay()
+	if delay > 0 && strings.HasSuffix(message.Stream, FifoSuffix) {
+		return 0, streams.ErrDelayNotSupported
+	} else if delay > SqsMaxDelay {
+		return 0, ErrDelayTooLong
+	}
+	return int32((delay + time.Second - 1) / time.Second), nil
 }
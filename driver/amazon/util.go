@@ -7,6 +7,17 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 )
 
+// FifoSuffix name suffix of Amazon SNS FIFO topics and Amazon SQS FIFO queues.
+const FifoSuffix = ".fifo"
+
+// sanitizeName replaces characters not allowed by Amazon resource names, keeping the FIFO suffix untouched.
+func sanitizeName(name string) string {
+	if strings.HasSuffix(name, FifoSuffix) {
+		return nameSanitizer.Replace(strings.TrimSuffix(name, FifoSuffix)) + FifoSuffix
+	}
+	return nameSanitizer.Replace(name)
+}
+
 const (
 	baseSnsTopicPrefix      = "arn:aws:sns:"
 	snsTopicTotalSeparators = 2
@@ -40,7 +51,7 @@ func NewTopic(region, accountID, baseTopic string) *string {
 	buff.WriteString(":")
 	buff.WriteString(accountID)
 	buff.WriteString(":")
-	buff.WriteString(nameSanitizer.Replace(baseTopic))
+	buff.WriteString(sanitizeName(baseTopic))
 	return aws.String(buff.String())
 }
 
@@ -67,7 +78,7 @@ func NewQueueUrl(region, accountID, queueName string) *string {
 	buff.WriteString(sqsDomain)
 	buff.WriteString(accountID)
 	buff.WriteString("/")
-	buff.WriteString(nameSanitizer.Replace(queueName))
+	buff.WriteString(sanitizeName(queueName))
 	return aws.String(buff.String())
 }
 
@@ -96,3 +107,21 @@ func NewEventBusArn(region, accountID, baseBusName string) *string {
 	buff.WriteString(baseBusName)
 	return aws.String(buff.String())
 }
+
+// newFifoString builds an optional FIFO-only Amazon API parameter (e.g. MessageGroupId), which is nil if the given
+// stream is not a FIFO topic or queue (FifoSuffix) as standard ones reject these parameters.
+func newFifoString(stream, v string) *string {
+	if !strings.HasSuffix(stream, FifoSuffix) {
+		return nil
+	}
+	return newOptionalString(v)
+}
+
+// newOptionalString builds an optional Amazon API parameter, which is nil if the given value is empty as Amazon
+// services reject empty optional parameters (e.g. MessageGroupId on a standard queue).
+func newOptionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return aws.String(v)
+}
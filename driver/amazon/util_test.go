@@ -45,6 +45,13 @@ func TestNewTopic(t *testing.T) {
 			InTopic:     "ncorp.prod.platform.foo_bar.lorem",
 			Exp:         aws.String("arn:aws:sns:us-east-2:123456789012:ncorp-prod-platform-foo_bar-lorem"),
 		},
+		{
+			Name:        "Valid FIFO",
+			InAccountID: "123456789012",
+			InRegion:    "us-east-2",
+			InTopic:     "ncorp.prod.platform.foo_bar.lorem.fifo",
+			Exp:         aws.String("arn:aws:sns:us-east-2:123456789012:ncorp-prod-platform-foo_bar-lorem.fifo"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -98,6 +105,13 @@ func TestNewQueueUrl(t *testing.T) {
 			InQueueName: "ncorp.dev.platform.foo_bar.notify_user.on.bar.activated",
 			Exp:         aws.String("https://sqs.us-west-1.amazonaws.com/123456789012/ncorp-dev-platform-foo_bar-notify_user-on-bar-activated"),
 		},
+		{
+			Name:        "Valid FIFO",
+			InAccountID: "123456789012",
+			InRegion:    "us-west-1",
+			InQueueName: "ncorp.dev.platform.foo_bar.notify_user.on.bar.activated.fifo",
+			Exp:         aws.String("https://sqs.us-west-1.amazonaws.com/123456789012/ncorp-dev-platform-foo_bar-notify_user-on-bar-activated.fifo"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,7 @@
+// Package filelog is the persistent file-backed log implementation for Streamhub-based programs.
+//
+// Messages are appended to per-stream segment files along an index, while consumer group offsets are tracked on disk.
+// Thus, streams survive program restarts and might be replayed from a specific offset or timestamp.
+//
+// Useful for local development and single-node deployments. A Log directory MUST NOT be shared between processes.
+package filelog
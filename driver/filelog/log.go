@@ -0,0 +1,387 @@
+package filelog
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/neutrinocorp/streams"
+)
+
+const (
+	streamsDirName = "streams"
+	groupsDirName  = "groups"
+	offsetFileExt  = ".offset"
+)
+
+var (
+	// ErrLogClosed the Log has been closed.
+	ErrLogClosed = errors.New("streams: File log has been closed")
+	// ErrInvalidName the stream or group name cannot be used as a file name.
+	ErrInvalidName = errors.New("streams: Invalid file log stream or group name")
+	// ErrCorruptedSegment a segment file is not consistent.
+	ErrCorruptedSegment = errors.New("streams: Corrupted file log segment")
+)
+
+// Config Log configuration.
+type Config struct {
+	// SegmentMaxBytes size a segment log file might reach before a new segment gets created.
+	SegmentMaxBytes int64
+	// SyncWrites flushes segment files to stable storage (fsync) after each write if true.
+	SyncWrites bool
+}
+
+// DefaultConfig default Log configuration.
+var DefaultConfig = Config{
+	SegmentMaxBytes: 64 << 20, // 64 MiB
+}
+
+// Record is a message stored in a stream of a Log.
+type Record struct {
+	// Offset sequential position of the record within its stream.
+	Offset int64
+	// Timestamp time the record was appended to the stream.
+	Timestamp time.Time
+	Message   streams.Message
+}
+
+// Log is an append-only, file-backed message storage organized by streams.
+//
+// Directory layout:
+//
+// - streams/{stream}/{base_offset}.log and streams/{stream}/{base_offset}.index segment files.
+//
+// - groups/{group}/{stream}.offset committed offset of a consumer group.
+type Log struct {
+	dir    string
+	config Config
+
+	mu      sync.Mutex
+	streams map[string]*streamLog
+	closed  bool
+}
+
+// Open opens (or creates) a Log in the given directory, recovering any partially written record.
+func Open(dir string, cfg Config) (*Log, error) {
+	if cfg.SegmentMaxBytes <= 0 {
+		cfg.SegmentMaxBytes = DefaultConfig.SegmentMaxBytes
+	}
+	if err := os.MkdirAll(filepath.Join(dir, streamsDirName), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, groupsDirName), 0o755); err != nil {
+		return nil, err
+	}
+	return &Log{
+		dir:     dir,
+		config:  cfg,
+		streams: map[string]*streamLog{},
+	}, nil
+}
+
+// Close closes every segment file. Further operations will fail with ErrLogClosed.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	errs := streams.MultiError{}
+	for _, s := range l.streams {
+		if err := s.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Append writes the given messages at the end of the given stream, retrieving the offset of the first message.
+func (l *Log) Append(stream string, messages ...streams.Message) (int64, error) {
+	s, err := l.stream(stream)
+	if err != nil {
+		return 0, err
+	}
+	payloads := make([][]byte, 0, len(messages))
+	for _, msg := range messages {
+		payload, errMarshal := jsoniter.Marshal(msg)
+		if errMarshal != nil {
+			return 0, errMarshal
+		}
+		payloads = append(payloads, payload)
+	}
+	return s.append(payloads)
+}
+
+// Read retrieves up to limit records of the given stream starting from the given offset.
+func (l *Log) Read(stream string, offset int64, limit int) ([]Record, error) {
+	s, err := l.stream(stream)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(offset, limit)
+}
+
+// EndOffset retrieves the offset the next message appended to the given stream will get.
+func (l *Log) EndOffset(stream string) (int64, error) {
+	s, err := l.stream(stream)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nextOffset(), nil
+}
+
+// OffsetForTime retrieves the offset of the first record of the given stream appended at or after the given time.
+//
+// If no record matches, the end offset of the stream is returned.
+func (l *Log) OffsetForTime(stream string, t time.Time) (int64, error) {
+	s, err := l.stream(stream)
+	if err != nil {
+		return 0, err
+	}
+	return s.offsetForTime(t.UnixNano()), nil
+}
+
+// CommittedOffset retrieves the offset the given consumer group will read next from the given stream.
+//
+// Returns false if the group has not committed any offset yet.
+func (l *Log) CommittedOffset(stream, group string) (int64, bool, error) {
+	path, err := l.offsetPath(stream, group)
+	if err != nil {
+		return 0, false, err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, false, ErrCorruptedSegment
+	}
+	return offset, true, nil
+}
+
+// Commit stores the offset the given consumer group will read next from the given stream.
+//
+// Might be used to replay a stream for a group, as long as none of its readers is running.
+func (l *Log) Commit(stream, group string, offset int64) error {
+	path, err := l.offsetPath(stream, group)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// write-and-rename so a crash never leaves a partially written offset
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (l *Log) offsetPath(stream, group string) (string, error) {
+	streamName, err := fileName(stream)
+	if err != nil {
+		return "", err
+	}
+	groupName, err := fileName(group)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, groupsDirName, groupName, streamName+offsetFileExt), nil
+}
+
+// fileName escapes the given stream or group name, so it can be used safely as a file name.
+func fileName(name string) (string, error) {
+	if name == "" || name == "." || name == ".." {
+		return "", ErrInvalidName
+	}
+	return url.PathEscape(name), nil
+}
+
+// stream retrieves the given stream, loading it from disk if required.
+func (l *Log) stream(name string) (*streamLog, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, ErrLogClosed
+	} else if s, ok := l.streams[name]; ok {
+		return s, nil
+	}
+
+	dirName, err := fileName(name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := openStreamLog(filepath.Join(l.dir, streamsDirName, dirName), l.config)
+	if err != nil {
+		return nil, err
+	}
+	l.streams[name] = s
+	return s, nil
+}
+
+// streamLog is the set of segments of a single stream.
+type streamLog struct {
+	dir    string
+	config Config
+
+	mu            sync.RWMutex
+	segments      []*segment
+	lastTimestamp int64
+	// notify is closed (and replaced) every time records are appended
+	notify chan struct{}
+}
+
+func openStreamLog(dir string, cfg Config) (*streamLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	bases, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		bases = append(bases, 0)
+	}
+	s := &streamLog{
+		dir:      dir,
+		config:   cfg,
+		segments: make([]*segment, 0, len(bases)),
+		notify:   make(chan struct{}),
+	}
+	for i, base := range bases {
+		seg, errOpen := openSegment(dir, base, i == len(bases)-1)
+		if errOpen != nil {
+			_ = s.close()
+			return nil, errOpen
+		}
+		s.segments = append(s.segments, seg)
+	}
+	if active := s.active(); len(active.entries) > 0 {
+		s.lastTimestamp = active.entries[len(active.entries)-1].timestamp
+	}
+	return s, nil
+}
+
+func (s *streamLog) active() *segment {
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *streamLog) nextOffset() int64 {
+	return s.active().nextOffset()
+}
+
+func (s *streamLog) append(payloads [][]byte) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := s.active()
+	if active.size >= s.config.SegmentMaxBytes && len(active.entries) > 0 {
+		seg, err := openSegment(s.dir, active.nextOffset(), false)
+		if err != nil {
+			return 0, err
+		}
+		s.segments = append(s.segments, seg)
+		active = seg
+	}
+
+	// timestamps are kept monotonic, so records might be searched by time
+	timestamp := time.Now().UnixNano()
+	if timestamp < s.lastTimestamp {
+		timestamp = s.lastTimestamp
+	}
+	offset := active.nextOffset()
+	if err := active.append(timestamp, payloads, s.config.SyncWrites); err != nil {
+		return 0, err
+	}
+	s.lastTimestamp = timestamp
+	close(s.notify)
+	s.notify = make(chan struct{})
+	return offset, nil
+}
+
+// wait retrieves a channel closed once new records are appended.
+func (s *streamLog) wait() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.notify
+}
+
+func (s *streamLog) read(offset int64, limit int) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if offset < 0 {
+		offset = 0
+	}
+	end := s.nextOffset()
+	if offset+int64(limit) < end {
+		end = offset + int64(limit)
+	}
+	if offset >= end {
+		return nil, nil
+	}
+
+	records := make([]Record, 0, end-offset)
+	i := sort.Search(len(s.segments), func(i int) bool {
+		return s.segments[i].baseOffset > offset
+	}) - 1
+	for ; offset < end; offset++ {
+		seg := s.segments[i]
+		if offset >= seg.nextOffset() {
+			i++
+			seg = s.segments[i]
+		}
+		payload, err := seg.read(offset)
+		if err != nil {
+			return records, err
+		}
+		record := Record{
+			Offset:    offset,
+			Timestamp: time.Unix(0, seg.entries[offset-seg.baseOffset].timestamp),
+		}
+		if err = jsoniter.Unmarshal(payload, &record.Message); err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (s *streamLog) offsetForTime(timestamp int64) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, seg := range s.segments {
+		i := sort.Search(len(seg.entries), func(i int) bool {
+			return seg.entries[i].timestamp >= timestamp
+		})
+		if i < len(seg.entries) {
+			return seg.baseOffset + int64(i)
+		}
+	}
+	return s.nextOffset()
+}
+
+func (s *streamLog) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := streams.MultiError{}
+	for _, seg := range s.segments {
+		if err := seg.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
@@ -0,0 +1,153 @@
+package filelog_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/driver/filelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMessages(stream string, from, n int) []streams.Message {
+	messages := make([]streams.Message, 0, n)
+	for i := from; i < from+n; i++ {
+		messages = append(messages, streams.Message{
+			ID:     strconv.Itoa(i),
+			Stream: stream,
+			Data:   []byte("foo"),
+		})
+	}
+	return messages
+}
+
+func messageIDs(records []filelog.Record) []string {
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.Message.ID)
+	}
+	return ids
+}
+
+func TestLog_Append(t *testing.T) {
+	dir := t.TempDir()
+	l, err := filelog.Open(dir, filelog.Config{SegmentMaxBytes: 256})
+	require.NoError(t, err)
+
+	_, err = l.Append("")
+	assert.ErrorIs(t, err, filelog.ErrInvalidName)
+	for i := 0; i < 10; i++ {
+		offset, errAppend := l.Append("foo-stream", newTestMessages("foo-stream", i, 1)...)
+		require.NoError(t, errAppend)
+		assert.Equal(t, int64(i), offset)
+	}
+	offset, err := l.Append("foo-stream", newTestMessages("foo-stream", 10, 5)...)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), offset)
+
+	// small segments force multiple segment files
+	segments, err := filepath.Glob(filepath.Join(dir, "streams", "foo-stream", "*.log"))
+	require.NoError(t, err)
+	assert.Greater(t, len(segments), 1)
+
+	records, err := l.Read("foo-stream", 3, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}, messageIDs(records))
+	assert.Equal(t, int64(3), records[0].Offset)
+	assert.Equal(t, []byte("foo"), records[0].Message.Data)
+	records, err = l.Read("foo-stream", 14, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"14"}, messageIDs(records))
+	records, err = l.Read("foo-stream", 15, 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	// reopen
+	require.NoError(t, l.Close())
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 15, 1)...)
+	assert.ErrorIs(t, err, filelog.ErrLogClosed)
+	l, err = filelog.Open(dir, filelog.Config{SegmentMaxBytes: 256})
+	require.NoError(t, err)
+	defer l.Close()
+	end, err := l.EndOffset("foo-stream")
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), end)
+	records, err = l.Read("foo-stream", 0, 100)
+	require.NoError(t, err)
+	assert.Len(t, records, 15)
+}
+
+func TestLog_Recovery(t *testing.T) {
+	dir := t.TempDir()
+	l, err := filelog.Open(dir, filelog.DefaultConfig)
+	require.NoError(t, err)
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 0, 3)...)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	// mimic a crash in the middle of a write
+	segment := filepath.Join(dir, "streams", "foo-stream", "00000000000000000000.log")
+	f, err := os.OpenFile(segment, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 42, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	l, err = filelog.Open(dir, filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+	offset, err := l.Append("foo-stream", newTestMessages("foo-stream", 3, 1)...)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), offset)
+	records, err := l.Read("foo-stream", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2", "3"}, messageIDs(records))
+}
+
+func TestLog_OffsetForTime(t *testing.T) {
+	l, err := filelog.Open(t.TempDir(), filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 0, 2)...)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 5)
+	checkpoint := time.Now()
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 2, 2)...)
+	require.NoError(t, err)
+
+	offset, err := l.OffsetForTime("foo-stream", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), offset)
+	offset, err = l.OffsetForTime("foo-stream", checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), offset)
+	offset, err = l.OffsetForTime("foo-stream", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), offset)
+}
+
+func TestLog_Commit(t *testing.T) {
+	dir := t.TempDir()
+	l, err := filelog.Open(dir, filelog.DefaultConfig)
+	require.NoError(t, err)
+
+	_, ok, err := l.CommittedOffset("foo-stream", "foo-group")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.ErrorIs(t, l.Commit("foo-stream", "..", 1), filelog.ErrInvalidName)
+	require.NoError(t, l.Commit("foo-stream", "foo-group", 5))
+	require.NoError(t, l.Close())
+
+	l, err = filelog.Open(dir, filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+	offset, ok, err := l.CommittedOffset("foo-stream", "foo-group")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), offset)
+}
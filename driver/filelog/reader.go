@@ -0,0 +1,318 @@
+package filelog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+)
+
+// StartPolicy defines the offset a consumer starts reading a stream from.
+type StartPolicy uint8
+
+const (
+	// StartCommitted starts from the offset committed by the consumer group. If the group has not committed any
+	// offset, it starts from the earliest record. Ungrouped tasks start from the latest record.
+	StartCommitted StartPolicy = iota
+	// StartEarliest replays the stream from its earliest record.
+	StartEarliest
+	// StartLatest skips every record appended before the task started.
+	StartLatest
+	// StartAtOffset replays the stream from ReaderConfig.StartOffset.
+	StartAtOffset
+	// StartAtTime replays the stream from the first record appended at or after ReaderConfig.StartTime.
+	StartAtTime
+)
+
+// ReaderConfig file-backed log stream-reading job configuration.
+//
+// Might be set per ReaderNode using streams.WithProviderConfiguration option. The start policy is applied only by
+// the first task of a consumer group.
+type ReaderConfig struct {
+	StartPolicy StartPolicy
+	StartOffset int64
+	StartTime   time.Time
+	// RetryInterval duration to wait before redelivering a record whose handler failed.
+	RetryInterval time.Duration
+}
+
+// DefaultReaderConfig default configuration of Reader stream-reading jobs.
+var DefaultReaderConfig = ReaderConfig{
+	StartPolicy:   StartCommitted,
+	RetryInterval: time.Second,
+}
+
+// Reader is the streams.Reader file-backed log implementation.
+//
+// Each record is delivered once per consumer group, load-balanced across the group's tasks. Group offsets are
+// committed on disk after records are processed; if a handler fails, the group's offset is not moved past its record
+// and the record gets redelivered (at-least-once delivery). Tasks without group each receive every record, and do not
+// commit offsets.
+type Reader struct {
+	log    *Log
+	config ReaderConfig
+
+	mu        sync.Mutex
+	consumers map[consumerKey]*consumer
+	jobs      sync.WaitGroup
+	done      chan struct{}
+	closed    bool
+}
+
+type consumerKey struct {
+	stream, group string
+	// task distinguishes ungrouped consumers as each one has its own position
+	task *groupTask
+}
+
+type groupTask struct {
+	ctx  context.Context
+	task streams.ReaderTask
+	sem  chan struct{}
+}
+
+type consumer struct {
+	key   consumerKey
+	tasks []*groupTask
+	next  int64
+}
+
+var (
+	_ streams.Reader     = &Reader{}
+	_ streams.Shutdowner = &Reader{}
+)
+
+// NewReader allocates a new Reader ready to read messages from the given Log.
+func NewReader(l *Log, cfg ReaderConfig) *Reader {
+	return &Reader{
+		log:       l,
+		config:    cfg,
+		consumers: map[consumerKey]*consumer{},
+		done:      make(chan struct{}),
+	}
+}
+
+// ExecuteTask joins the task into its consumer group for the task stream, starting a background stream-reading job
+// if the group had no running tasks.
+//
+// The task leaves the group once the given context is canceled.
+func (r *Reader) ExecuteTask(ctx context.Context, task streams.ReaderTask) error {
+	if task.HandlerFunc == nil {
+		return streams.ErrMissingReaderHandler
+	}
+	cfg := r.config
+	if scopedCfg, ok := task.Configuration.(ReaderConfig); ok {
+		cfg = scopedCfg
+	}
+	poolSize := task.MaxHandlerPoolSize
+	if poolSize <= 0 {
+		poolSize = streams.DefaultMaxHandlerPoolSize
+	}
+	t := &groupTask{
+		ctx:  ctx,
+		task: task,
+		sem:  make(chan struct{}, poolSize),
+	}
+	key := consumerKey{stream: task.Stream, group: task.Group}
+	if task.Group == "" {
+		key.task = t
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrLogClosed
+	}
+	c, ok := r.consumers[key]
+	if !ok {
+		next, err := r.startOffset(key, cfg)
+		if err != nil {
+			return err
+		}
+		c = &consumer{key: key, next: next}
+		r.consumers[key] = c
+		r.jobs.Add(1)
+		go func() {
+			defer r.jobs.Done()
+			r.consume(c, cfg)
+		}()
+	}
+	c.tasks = append(c.tasks, t)
+	return nil
+}
+
+func (r *Reader) startOffset(key consumerKey, cfg ReaderConfig) (int64, error) {
+	switch cfg.StartPolicy {
+	case StartEarliest:
+		return 0, nil
+	case StartLatest:
+		return r.log.EndOffset(key.stream)
+	case StartAtOffset:
+		return cfg.StartOffset, nil
+	case StartAtTime:
+		return r.log.OffsetForTime(key.stream, cfg.StartTime)
+	}
+	if key.group == "" {
+		return r.log.EndOffset(key.stream)
+	}
+	offset, _, err := r.log.CommittedOffset(key.stream, key.group)
+	return offset, err
+}
+
+// Shutdown stops every stream-reading job and waits for in-flight handlers to finish. Offsets of processed records
+// are committed before returning.
+func (r *Reader) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+	}
+	r.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		r.jobs.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// activeTasks retrieves the tasks of the given consumer whose context is alive. If none, the consumer is removed.
+func (r *Reader) activeTasks(c *consumer) []*groupTask {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tasks := c.tasks[:0]
+	for _, t := range c.tasks {
+		if t.ctx.Err() == nil {
+			tasks = append(tasks, t)
+		}
+	}
+	c.tasks = tasks
+	if len(tasks) == 0 {
+		delete(r.consumers, c.key)
+		return nil
+	}
+	return append([]*groupTask(nil), tasks...)
+}
+
+// consume reads records from the consumer position, dispatching them to the consumer tasks until every task
+// leaves or the Reader is shut down.
+func (r *Reader) consume(c *consumer, cfg ReaderConfig) {
+	stream, err := r.log.stream(c.key.stream)
+	if err != nil {
+		return
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = DefaultReaderConfig.RetryInterval
+	}
+	for {
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+		tasks := r.activeTasks(c)
+		if len(tasks) == 0 {
+			return
+		}
+
+		notify := stream.wait()
+		batchSize := 0
+		for _, t := range tasks {
+			batchSize += cap(t.sem)
+		}
+		records, errRead := stream.read(c.next, batchSize)
+		if errRead != nil || len(records) == 0 {
+			wait := cfg.RetryInterval
+			if errRead == nil {
+				wait = -1
+			}
+			if !r.sleep(notify, tasks, wait) {
+				return
+			}
+			continue
+		}
+
+		next := r.dispatch(tasks, records)
+		if c.key.group != "" && next != c.next {
+			_ = r.log.Commit(c.key.stream, c.key.group, next)
+		}
+		failed := next <= records[len(records)-1].Offset
+		c.next = next
+		if failed && !r.sleep(nil, tasks, cfg.RetryInterval) {
+			return
+		}
+	}
+}
+
+// sleep waits until the notify channel is closed or the given duration elapsed (if positive).
+//
+// Returns false if the Reader is shut down; every task left while waiting makes sleep return early.
+func (r *Reader) sleep(notify <-chan struct{}, tasks []*groupTask, d time.Duration) bool {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-r.done:
+		return false
+	case <-notify:
+	case <-timeout:
+	case <-tasks[0].ctx.Done():
+	}
+	return true
+}
+
+// dispatch executes the handlers of the given records, load-balancing them across the given tasks.
+//
+// Returns the offset to be read next: the offset of the first failed record or, if every handler succeeded, the
+// offset after the last record.
+func (r *Reader) dispatch(tasks []*groupTask, records []Record) int64 {
+	errs := make([]error, len(records))
+	wg := sync.WaitGroup{}
+	for i, record := range records {
+		t := tasks[i%len(tasks)]
+		select {
+		case t.sem <- struct{}{}:
+		case <-t.ctx.Done():
+			errs[i] = t.ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, t *groupTask, message streams.Message) {
+			defer wg.Done()
+			defer func() { <-t.sem }()
+			errs[i] = t.execute(message)
+		}(i, t, record.Message)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return records[i].Offset
+		}
+	}
+	return records[len(records)-1].Offset + 1
+}
+
+func (t *groupTask) execute(message streams.Message) error {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if t.task.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(t.ctx, t.task.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(t.ctx)
+	}
+	defer cancel()
+	return t.task.HandlerFunc(ctx, message)
+}
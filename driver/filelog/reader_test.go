@@ -0,0 +1,217 @@
+package filelog_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/driver/filelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver collects the IDs of messages received by each task.
+type receiver struct {
+	mu       sync.Mutex
+	received map[string][]string
+}
+
+func newReceiver() *receiver {
+	return &receiver{received: map[string][]string{}}
+}
+
+func (r *receiver) handler(task string) streams.ReaderHandleFunc {
+	return func(_ context.Context, message streams.Message) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.received[task] = append(r.received[task], message.ID)
+		return nil
+	}
+}
+
+func (r *receiver) count(tasks ...string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, t := range tasks {
+		n += len(r.received[t])
+	}
+	return n
+}
+
+func (r *receiver) ids(tasks ...string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0)
+	for _, t := range tasks {
+		ids = append(ids, r.received[t]...)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestReader_ExecuteTask(t *testing.T) {
+	l, err := filelog.Open(t.TempDir(), filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 0, 4)...)
+	require.NoError(t, err)
+
+	reader := filelog.NewReader(l, filelog.DefaultReaderConfig)
+	defer reader.Shutdown(context.Background())
+	err = reader.ExecuteTask(context.Background(), streams.ReaderTask{Stream: "foo-stream"})
+	assert.ErrorIs(t, err, streams.ErrMissingReaderHandler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recv := newReceiver()
+	// two tasks of group-a are load-balanced, while group-b and ungrouped tasks get their own copy
+	for _, task := range []struct{ name, group string }{
+		{"a1", "group-a"}, {"a2", "group-a"}, {"b1", "group-b"}, {"u1", ""},
+	} {
+		err = reader.ExecuteTask(ctx, streams.ReaderTask{
+			Stream:      "foo-stream",
+			Group:       task.group,
+			HandlerFunc: recv.handler(task.name),
+		})
+		require.NoError(t, err)
+	}
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 4, 2)...)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return recv.count("a1", "a2") == 6 && recv.count("b1") == 6 && recv.count("u1") == 2
+	}, time.Second, time.Millisecond*10)
+	exp := []string{"0", "1", "2", "3", "4", "5"}
+	assert.Equal(t, exp, recv.ids("a1", "a2"))
+	assert.NotEmpty(t, recv.ids("a1"))
+	assert.NotEmpty(t, recv.ids("a2"))
+	assert.Equal(t, exp, recv.ids("b1"))
+	// ungrouped tasks start from the latest record
+	assert.Equal(t, []string{"4", "5"}, recv.ids("u1"))
+
+	assert.Eventually(t, func() bool {
+		offset, _, _ := l.CommittedOffset("foo-stream", "group-b")
+		return offset == 6
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestReader_ExecuteTask_Resume(t *testing.T) {
+	l, err := filelog.Open(t.TempDir(), filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 0, 3)...)
+	require.NoError(t, err)
+
+	recv := newReceiver()
+	reader := filelog.NewReader(l, filelog.DefaultReaderConfig)
+	err = reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream:      "foo-stream",
+		Group:       "foo-group",
+		HandlerFunc: recv.handler("first"),
+	})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return recv.count("first") == 3
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, reader.Shutdown(context.Background()))
+	assert.ErrorIs(t, reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream:      "foo-stream",
+		HandlerFunc: recv.handler("first"),
+	}), filelog.ErrLogClosed)
+
+	// a new reader of the same group continues from the committed offset
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 3, 2)...)
+	require.NoError(t, err)
+	reader = filelog.NewReader(l, filelog.DefaultReaderConfig)
+	defer reader.Shutdown(context.Background())
+	err = reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream:      "foo-stream",
+		Group:       "foo-group",
+		HandlerFunc: recv.handler("second"),
+	})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return recv.count("second") == 2
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, []string{"3", "4"}, recv.ids("second"))
+}
+
+func TestReader_ExecuteTask_Replay(t *testing.T) {
+	l, err := filelog.Open(t.TempDir(), filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 0, 2)...)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 5)
+	checkpoint := time.Now()
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 2, 3)...)
+	require.NoError(t, err)
+	require.NoError(t, l.Commit("foo-stream", "foo-group", 5))
+
+	recv := newReceiver()
+	reader := filelog.NewReader(l, filelog.DefaultReaderConfig)
+	defer reader.Shutdown(context.Background())
+	err = reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream:        "foo-stream",
+		Group:         "foo-group",
+		HandlerFunc:   recv.handler("offset"),
+		Configuration: filelog.ReaderConfig{StartPolicy: filelog.StartAtOffset, StartOffset: 3},
+	})
+	require.NoError(t, err)
+	err = reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream:        "foo-stream",
+		Group:         "bar-group",
+		HandlerFunc:   recv.handler("time"),
+		Configuration: filelog.ReaderConfig{StartPolicy: filelog.StartAtTime, StartTime: checkpoint},
+	})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return recv.count("offset") == 2 && recv.count("time") == 3
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, []string{"3", "4"}, recv.ids("offset"))
+	assert.Equal(t, []string{"2", "3", "4"}, recv.ids("time"))
+}
+
+func TestReader_ExecuteTask_Redelivery(t *testing.T) {
+	l, err := filelog.Open(t.TempDir(), filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+	_, err = l.Append("foo-stream", newTestMessages("foo-stream", 0, 3)...)
+	require.NoError(t, err)
+
+	mu := sync.Mutex{}
+	attempts := map[string]int{}
+	reader := filelog.NewReader(l, filelog.DefaultReaderConfig)
+	defer reader.Shutdown(context.Background())
+	err = reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream: "foo-stream",
+		Group:  "foo-group",
+		HandlerFunc: func(_ context.Context, message streams.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[message.ID]++
+			if message.ID == "1" && attempts[message.ID] == 1 {
+				return errors.New("generic error")
+			}
+			return nil
+		},
+		Configuration: filelog.ReaderConfig{RetryInterval: time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		offset, _, _ := l.CommittedOffset("foo-stream", "foo-group")
+		return offset == 3
+	}, time.Second, time.Millisecond*10)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, attempts["0"])
+	assert.Equal(t, 2, attempts["1"])
+	// records after the failed one are redelivered too (at-least-once delivery)
+	assert.GreaterOrEqual(t, attempts["2"], 1)
+}
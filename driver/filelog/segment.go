@@ -0,0 +1,245 @@
+package filelog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	segmentLogExtension   = ".log"
+	segmentIndexExtension = ".index"
+	// recordHeaderSize length (4 bytes), checksum (4 bytes) and write timestamp (8 bytes) of a record.
+	recordHeaderSize = 16
+	// indexEntrySize record position (8 bytes) and write timestamp (8 bytes) of an index entry.
+	indexEntrySize = 16
+)
+
+// indexEntry location of a record within a segment log file.
+type indexEntry struct {
+	position  int64
+	timestamp int64
+}
+
+// segment is a pair of append-only files holding a contiguous range of stream records starting from baseOffset.
+//
+// The log file contains records with the following layout: length | crc32 | timestamp | payload. The index file
+// contains an entry (position | timestamp) for each record, so records might be located by offset or timestamp
+// without scanning the log file.
+type segment struct {
+	baseOffset int64
+	log        *os.File
+	index      *os.File
+	entries    []indexEntry
+	size       int64
+}
+
+func segmentFileName(baseOffset int64) string {
+	return fmt.Sprintf("%020d", baseOffset)
+}
+
+// listSegments retrieves the base offset of every segment in the given directory in ascending order.
+func listSegments(dir string) ([]int64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	bases := make([]int64, 0, len(files))
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, segmentLogExtension) {
+			continue
+		}
+		base, errParse := strconv.ParseInt(strings.TrimSuffix(name, segmentLogExtension), 10, 64)
+		if errParse != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	// file names are zero-padded, so directory order is offset order
+	return bases, nil
+}
+
+// openSegment opens (or creates) the segment starting at the given offset.
+//
+// If recover is true, the index is rebuilt from the log file, truncating any partially written record (e.g. after
+// a crash). Otherwise, the index file is trusted if its size is consistent.
+func openSegment(dir string, baseOffset int64, recover bool) (*segment, error) {
+	path := filepath.Join(dir, segmentFileName(baseOffset))
+	logFile, err := os.OpenFile(path+segmentLogExtension, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	indexFile, err := os.OpenFile(path+segmentIndexExtension, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		_ = logFile.Close()
+		return nil, err
+	}
+	s := &segment{
+		baseOffset: baseOffset,
+		log:        logFile,
+		index:      indexFile,
+	}
+
+	if !recover {
+		err = s.loadIndex()
+	}
+	if recover || err != nil {
+		err = s.rebuildIndex()
+	}
+	if err != nil {
+		_ = s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *segment) loadIndex() error {
+	logInfo, err := s.log.Stat()
+	if err != nil {
+		return err
+	}
+	indexInfo, err := s.index.Stat()
+	if err != nil {
+		return err
+	} else if indexInfo.Size()%indexEntrySize != 0 {
+		return ErrCorruptedSegment
+	}
+	raw := make([]byte, indexInfo.Size())
+	if _, err = s.index.ReadAt(raw, 0); err != nil && err != io.EOF {
+		return err
+	}
+	s.entries = make([]indexEntry, 0, len(raw)/indexEntrySize)
+	for i := 0; i < len(raw); i += indexEntrySize {
+		s.entries = append(s.entries, indexEntry{
+			position:  int64(binary.BigEndian.Uint64(raw[i:])),
+			timestamp: int64(binary.BigEndian.Uint64(raw[i+8:])),
+		})
+	}
+	s.size = logInfo.Size()
+	return nil
+}
+
+// rebuildIndex scans the log file validating every record, truncating both files after the last valid record.
+func (s *segment) rebuildIndex() error {
+	s.entries = s.entries[:0]
+	var position int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := s.log.ReadAt(header, position); err != nil {
+			break
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		payload := make([]byte, length+8)
+		if _, err := s.log.ReadAt(payload[8:], position+recordHeaderSize); err != nil {
+			break
+		}
+		copy(payload, header[8:])
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			break
+		}
+		s.entries = append(s.entries, indexEntry{
+			position:  position,
+			timestamp: int64(binary.BigEndian.Uint64(header[8:])),
+		})
+		position += recordHeaderSize + length
+	}
+	s.size = position
+	if err := s.log.Truncate(position); err != nil {
+		return err
+	}
+
+	raw := make([]byte, 0, len(s.entries)*indexEntrySize)
+	for _, e := range s.entries {
+		raw = appendIndexEntry(raw, e)
+	}
+	if err := s.index.Truncate(0); err != nil {
+		return err
+	}
+	_, err := s.index.WriteAt(raw, 0)
+	return err
+}
+
+func appendIndexEntry(buf []byte, e indexEntry) []byte {
+	var raw [indexEntrySize]byte
+	binary.BigEndian.PutUint64(raw[:], uint64(e.position))
+	binary.BigEndian.PutUint64(raw[8:], uint64(e.timestamp))
+	return append(buf, raw[:]...)
+}
+
+// nextOffset retrieves the offset the next appended record will get.
+func (s *segment) nextOffset() int64 {
+	return s.baseOffset + int64(len(s.entries))
+}
+
+// append writes the given record payloads at the end of the segment.
+func (s *segment) append(timestamp int64, payloads [][]byte, sync bool) error {
+	var (
+		buf      []byte
+		indexBuf = make([]byte, 0, len(payloads)*indexEntrySize)
+		entries  = make([]indexEntry, 0, len(payloads))
+		position = s.size
+	)
+	for _, payload := range payloads {
+		header := make([]byte, recordHeaderSize)
+		binary.BigEndian.PutUint32(header, uint32(len(payload)))
+		binary.BigEndian.PutUint64(header[8:], uint64(timestamp))
+		checksum := crc32.NewIEEE()
+		_, _ = checksum.Write(header[8:])
+		_, _ = checksum.Write(payload)
+		binary.BigEndian.PutUint32(header[4:], checksum.Sum32())
+		buf = append(buf, header...)
+		buf = append(buf, payload...)
+
+		entry := indexEntry{position: position, timestamp: timestamp}
+		entries = append(entries, entry)
+		indexBuf = appendIndexEntry(indexBuf, entry)
+		position += int64(len(header) + len(payload))
+	}
+
+	// log file is written first, so a partially written index gets rebuilt on recovery
+	if _, err := s.log.WriteAt(buf, s.size); err != nil {
+		return err
+	}
+	if _, err := s.index.WriteAt(indexBuf, int64(len(s.entries))*indexEntrySize); err != nil {
+		return err
+	}
+	if sync {
+		if err := s.log.Sync(); err != nil {
+			return err
+		}
+		if err := s.index.Sync(); err != nil {
+			return err
+		}
+	}
+	s.entries = append(s.entries, entries...)
+	s.size = position
+	return nil
+}
+
+// read retrieves the payload of the record located at the given offset.
+func (s *segment) read(offset int64) ([]byte, error) {
+	entry := s.entries[offset-s.baseOffset]
+	header := make([]byte, recordHeaderSize)
+	if _, err := s.log.ReadAt(header, entry.position); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := s.log.ReadAt(payload, entry.position+recordHeaderSize); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func (s *segment) close() error {
+	errLog := s.log.Close()
+	if err := s.index.Close(); err != nil {
+		return err
+	}
+	return errLog
+}
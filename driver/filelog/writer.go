@@ -0,0 +1,60 @@
+package filelog
+
+import (
+	"context"
+
+	"github.com/neutrinocorp/streams"
+)
+
+// Writer is the streams.Writer file-backed log implementation.
+//
+// Appended messages are readable at once, so messages with a delivery time (streams.Message DeliverAt) fail with
+// streams.ErrDelayNotSupported.
+type Writer struct {
+	log *Log
+}
+
+var _ streams.Writer = Writer{}
+
+// NewWriter allocates a new Writer ready to append messages into the given Log.
+func NewWriter(l *Log) Writer {
+	return Writer{log: l}
+}
+
+// Write appends the given message at the end of its stream.
+func (w Writer) Write(_ context.Context, message streams.Message) error {
+	if message.Delay() > 0 {
+		return streams.ErrDelayNotSupported
+	}
+	_, err := w.log.Append(message.Stream, message)
+	return err
+}
+
+// WriteBatch appends the given set of messages at the end of their streams. Messages of the same stream are
+// appended at once, so they either succeed or fail together.
+func (w Writer) WriteBatch(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	streamOrder := make([]string, 0)
+	batches := map[string][]streams.Message{}
+	indexes := map[string][]int{}
+	for i, msg := range messages {
+		if msg.Delay() > 0 {
+			res[i].Err = streams.ErrDelayNotSupported
+			continue
+		}
+		if _, ok := batches[msg.Stream]; !ok {
+			streamOrder = append(streamOrder, msg.Stream)
+		}
+		batches[msg.Stream] = append(batches[msg.Stream], msg)
+		indexes[msg.Stream] = append(indexes[msg.Stream], i)
+	}
+
+	for _, stream := range streamOrder {
+		if _, err := w.log.Append(stream, batches[stream]...); err != nil {
+			for _, i := range indexes[stream] {
+				res[i].Err = err
+			}
+		}
+	}
+	return res, res.Err()
+}
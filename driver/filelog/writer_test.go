@@ -0,0 +1,58 @@
+package filelog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/driver/filelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_Write(t *testing.T) {
+	l, err := filelog.Open(t.TempDir(), filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+
+	w := filelog.NewWriter(l)
+	require.NoError(t, w.Write(context.Background(), streams.Message{ID: "1", Stream: "foo-stream"}))
+	assert.ErrorIs(t, w.Write(context.Background(), streams.Message{ID: "2"}), filelog.ErrInvalidName)
+
+	res, err := w.WriteBatch(context.Background(),
+		streams.Message{ID: "2", Stream: "foo-stream"},
+		streams.Message{ID: "3", Stream: "bar-stream"},
+		streams.Message{ID: "4"},
+		streams.Message{ID: "5", Stream: "foo-stream"})
+	assert.ErrorIs(t, err, filelog.ErrInvalidName)
+	assert.Equal(t, uint32(3), res.Succeeded())
+	assert.ErrorIs(t, res[2].Err, filelog.ErrInvalidName)
+
+	records, err := l.Read("foo-stream", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "5"}, messageIDs(records))
+	records, err = l.Read("bar-stream", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, messageIDs(records))
+}
+
+func TestWriter_Write_Delay(t *testing.T) {
+	l, err := filelog.Open(t.TempDir(), filelog.DefaultConfig)
+	require.NoError(t, err)
+	defer l.Close()
+
+	deliverAt := time.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano)
+	w := filelog.NewWriter(l)
+	assert.ErrorIs(t, w.Write(context.Background(), streams.Message{ID: "1", Stream: "foo-stream",
+		DeliverAt: deliverAt}), streams.ErrDelayNotSupported)
+	res, err := w.WriteBatch(context.Background(),
+		streams.Message{ID: "2", Stream: "foo-stream"},
+		streams.Message{ID: "3", Stream: "foo-stream", DeliverAt: deliverAt})
+	assert.ErrorIs(t, err, streams.ErrDelayNotSupported)
+	assert.ErrorIs(t, res[1].Err, streams.ErrDelayNotSupported)
+
+	records, err := l.Read("foo-stream", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, messageIDs(records))
+}
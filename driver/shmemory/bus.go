@@ -2,86 +2,383 @@ package shmemory
 
 import (
 	"context"
+	"hash/fnv"
+	"sync"
+	"time"
 
 	"github.com/neutrinocorp/streams"
 )
 
 // Bus is an in-memory message broker to enable interactions between publishers and stream-listeners
+//
+// Written messages are held in a bounded buffer; once full, the Bus applies its WritePolicy. The Bus gets closed
+// when the context passed on startup is canceled or Shutdown is called: further writes fail with ErrBusClosed,
+// buffered messages are discarded and in-flight handlers are waited for.
+//
+// Messages sharing a partition key are handled sequentially, in the order they were written, by each reader group.
+//
+// Messages with a delivery time (streams.Message DeliverAt) are held by a timer until then; delayed messages are
+// discarded when the Bus gets closed.
+//
+// Handlers might request the redelivery of a message to their reader group using its streams.Acknowledger (Nack).
+// Acknowledging or terminating a message has no effect as the Bus holds no state of delivered messages.
 type Bus struct {
 	messageBuffer chan streams.Message
-	// key: Stream name | value: List of handlers
-	messageHandlers map[string][]streams.ReaderTask
+	// requeued messages rejected by a reader group, waiting to be delivered again to the same group
+	requeued chan requeuedMessage
+	// key: Stream name | value: List of reader groups
+	messageHandlers map[string][]*readerGroup
+	mu              sync.RWMutex
 
-	startedBus    bool
+	writePolicy WritePolicy
+	// delayed timers of messages waiting for their delivery time
+	delayed    map[*time.Timer]struct{}
+	startedBus bool
+	closedBus  bool
+	done       chan struct{}
+	// stopped is closed once every in-flight handler has finished after closing the Bus
+	stopped       chan struct{}
 	maxGoroutines int
 }
 
 // NewBus allocates a new Bus ready to be used
-func NewBus(maxGoroutines int) *Bus {
+func NewBus(maxGoroutines int, opts ...BusOption) *Bus {
 	if maxGoroutines <= 0 {
 		maxGoroutines = 100
 	}
+	baseOpts := busOptions{
+		bufferCapacity: DefaultBufferCapacity,
+		writePolicy:    WritePolicyBlock,
+	}
+	for _, o := range opts {
+		o.apply(&baseOpts)
+	}
 	return &Bus{
-		messageBuffer:   make(chan streams.Message),
-		messageHandlers: map[string][]streams.ReaderTask{},
+		messageBuffer:   make(chan streams.Message, baseOpts.bufferCapacity),
+		requeued:        make(chan requeuedMessage),
+		messageHandlers: map[string][]*readerGroup{},
+		writePolicy:     baseOpts.writePolicy,
+		delayed:         map[*time.Timer]struct{}{},
 		startedBus:      false,
+		done:            make(chan struct{}),
+		stopped:         make(chan struct{}),
 		maxGoroutines:   maxGoroutines,
 	}
 }
 
+// readerGroup is a set of tasks sharing a consumer group. Each message is delivered to a single task of the group.
+//
+// Messages with a partition key are delivered in order within the group: they are always delivered to the same
+// task and a message is not handled until the previous message with the same key was handled.
+type readerGroup struct {
+	name string
+	// nodeID identifies the ReaderNode of groups holding tasks without group
+	nodeID string
+	mu     sync.Mutex
+	tasks  []streams.ReaderTask
+	next   uint32
+	// key: Partition key | value: closed once the last scheduled message with the key was handled
+	tails map[string]chan struct{}
+}
+
+// schedule retrieves the task to deliver the next message to, using round-robin for messages without partition key
+// and key hashing otherwise.
+//
+// Moreover, returns a channel closed once the previous message with the same partition key was handled (nil if
+// there is no such message) and a function to be called once the message was handled.
+func (g *readerGroup) schedule(partitionKey string) (task streams.ReaderTask, prev <-chan struct{}, release func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if partitionKey == "" {
+		g.next++
+		return g.tasks[(g.next-1)%uint32(len(g.tasks))], nil, func() {}
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(partitionKey))
+	task = g.tasks[hash.Sum32()%uint32(len(g.tasks))]
+	prev = g.tails[partitionKey]
+	tail := make(chan struct{})
+	g.tails[partitionKey] = tail
+	return task, prev, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.tails[partitionKey] == tail {
+			delete(g.tails, partitionKey)
+		}
+		close(tail)
+	}
+}
+
+// registerHandler joins the given task into its reader group. Tasks without group are grouped by the ReaderNode they
+// were forked from, so concurrent copies of a node share its messages; tasks without group nor node are registered as
+// single-task groups, so each one receives every message of the stream.
 func (b *Bus) registerHandler(task streams.ReaderTask) {
-	handlers, ok := b.messageHandlers[task.Stream]
-	if !ok {
-		handlers = make([]streams.ReaderTask, 0)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	groups := b.messageHandlers[task.Stream]
+	nodeID := ""
+	if task.Group == "" {
+		nodeID = task.NodeID
 	}
+	if task.Group != "" || nodeID != "" {
+		for _, g := range groups {
+			if g.name == task.Group && g.nodeID == nodeID {
+				g.mu.Lock()
+				g.tasks = append(g.tasks, task)
+				g.mu.Unlock()
+				return
+			}
+		}
+	}
+	b.messageHandlers[task.Stream] = append(groups, &readerGroup{
+		name:   task.Group,
+		nodeID: nodeID,
+		tasks:  []streams.ReaderTask{task},
+		tails:  map[string]chan struct{}{},
+	})
+}
 
-	handlers = append(handlers, task)
-	b.messageHandlers[task.Stream] = handlers
+// readerGroups retrieves the reader groups subscribed to the given stream.
+func (b *Bus) readerGroups(stream string) []*readerGroup {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	groups := make([]*readerGroup, len(b.messageHandlers[stream]))
+	copy(groups, b.messageHandlers[stream])
+	return groups
 }
 
-func (b *Bus) write(_ context.Context, message streams.Message) error {
-	if !b.startedBus {
+func (b *Bus) write(ctx context.Context, message streams.Message) error {
+	b.mu.RLock()
+	started, closed := b.startedBus, b.closedBus
+	b.mu.RUnlock()
+	if !started {
 		return ErrBusNotStarted
+	} else if closed {
+		return ErrBusClosed
 	}
-	b.messageBuffer <- message
+	if delay := message.Delay(); delay > 0 {
+		return b.writeDelayed(message, delay)
+	}
+	return b.push(ctx, message)
+}
+
+// writeDelayed holds the given message until the given delay has elapsed, pushing it into the message buffer
+// afterwards.
+func (b *Bus) writeDelayed(message streams.Message, delay time.Duration) error {
+	return b.afterFunc(delay, func() {
+		_ = b.push(context.Background(), message)
+	})
+}
+
+type requeuedMessage struct {
+	group   *readerGroup
+	message streams.Message
+}
+
+// requeue delivers the given message again to the given reader group once the given delay has elapsed.
+func (b *Bus) requeue(g *readerGroup, message streams.Message, delay time.Duration) error {
+	return b.afterFunc(delay, func() {
+		select {
+		case <-b.done:
+		case b.requeued <- requeuedMessage{group: g, message: message}:
+		}
+	})
+}
+
+// afterFunc executes the given function once the given delay has elapsed, unless the Bus gets closed before.
+func (b *Bus) afterFunc(delay time.Duration, f func()) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closedBus {
+		return ErrBusClosed
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		b.mu.Lock()
+		_, pending := b.delayed[timer]
+		delete(b.delayed, timer)
+		b.mu.Unlock()
+		if pending {
+			f()
+		}
+	})
+	b.delayed[timer] = struct{}{}
 	return nil
 }
 
+// push inserts the given message into the message buffer, applying the Bus WritePolicy if the buffer is full.
+func (b *Bus) push(ctx context.Context, message streams.Message) error {
+	select {
+	case <-b.done:
+		return ErrBusClosed
+	default:
+	}
+	switch b.writePolicy {
+	case WritePolicyFailFast:
+		select {
+		case b.messageBuffer <- message:
+			return nil
+		default:
+			return ErrBusFull
+		}
+	case WritePolicyDropOldest:
+		for {
+			select {
+			case <-b.done:
+				return ErrBusClosed
+			case b.messageBuffer <- message:
+				return nil
+			default:
+			}
+			// make room and try again as the dispatcher might have taken messages in between
+			select {
+			case <-b.messageBuffer:
+			default:
+			}
+		}
+	default:
+		select {
+		case <-b.done:
+			return ErrBusClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		case b.messageBuffer <- message:
+			return nil
+		}
+	}
+}
+
 // start listen to the underlying message buffer queue that will be later used by publishers.
 // Inner operations will schedule stream-listeners if subscribed to the arrived message stream.
 //
-// In addition, the bus contains a very basic boolean lock to avoid multiple message buffer listening jobs running
-// concurrently.
+// The message buffer listening job runs once; the Bus gets closed when the given context is canceled.
 func (b *Bus) start(ctx context.Context) {
-	if b.startedBus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.startedBus || b.closedBus {
 		return
 	}
 	go func() {
+		defer close(b.stopped)
 		sem := make(chan struct{}, b.maxGoroutines)
-		for msg := range b.messageBuffer {
-			select {
-			case sem <- struct{}{}:
+		defer func() {
+			// wait for in-flight handlers by acquiring every slot
+			for n := 0; n < b.maxGoroutines; n++ {
+				sem <- struct{}{}
 			}
-			for _, t := range b.messageHandlers[msg.Stream] {
-				go func(task streams.ReaderTask, message streams.Message) {
-					scopedCtx, cancel := context.WithTimeout(ctx, task.Timeout)
-					defer cancel()
-					defer func() { <-sem }()
-					_ = task.HandlerFunc(scopedCtx, message)
-				}(t, msg)
+		}()
+		for {
+			select {
+			case <-b.done:
+				return
+			case msg := <-b.messageBuffer:
+				if !b.dispatch(ctx, sem, msg) {
+					return
+				}
+			case msg := <-b.requeued:
+				if !b.dispatchGroup(ctx, sem, msg.group, msg.message) {
+					return
+				}
 			}
 		}
-		// acquire semaphore
-		for n := 0; n < b.maxGoroutines; n++ {
-			sem <- struct{}{}
-		}
 	}()
 	go func() {
-		select {
-		case <-ctx.Done():
-			close(b.messageBuffer)
-			return
-		}
+		<-ctx.Done()
+		b.close()
 	}()
 	b.startedBus = true
 }
+
+// dispatch schedules a handler of each reader group subscribed to the message stream.
+//
+// A slot from the given semaphore is acquired per scheduled handler and released once the handler finished.
+// Handlers of messages with a partition key wait for the previous message with the same key to be handled; as slots
+// are acquired in message order, the oldest message of a key always holds a slot.
+//
+// Returns false if the Bus was closed while waiting for a slot.
+func (b *Bus) dispatch(ctx context.Context, sem chan struct{}, message streams.Message) bool {
+	for _, g := range b.readerGroups(message.Stream) {
+		if !b.dispatchGroup(ctx, sem, g, message) {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatchGroup schedules a handler of the given reader group.
+func (b *Bus) dispatchGroup(ctx context.Context, sem chan struct{}, g *readerGroup, message streams.Message) bool {
+	select {
+	case <-b.done:
+		return false
+	case sem <- struct{}{}:
+	}
+	task, prev, release := g.schedule(message.PartitionKey)
+	go func() {
+		defer func() { <-sem }()
+		defer release()
+		if prev != nil {
+			<-prev
+		}
+		scopedCtx, cancel := context.WithTimeout(ctx, task.Timeout)
+		defer cancel()
+		ack := streams.NewOnceAcknowledger(acknowledger{bus: b, group: g, message: message})
+		delivered := message
+		delivered.Acknowledger = ack
+		_ = task.HandlerFunc(streams.ContextWithAcknowledger(scopedCtx, ack), delivered)
+	}()
+	return true
+}
+
+// acknowledger is the streams.Acknowledger in-memory implementation.
+type acknowledger struct {
+	bus     *Bus
+	group   *readerGroup
+	message streams.Message
+}
+
+var _ streams.Acknowledger = acknowledger{}
+
+func (a acknowledger) Ack(_ context.Context) error {
+	return nil
+}
+
+func (a acknowledger) Nack(_ context.Context, requeueAfter time.Duration) error {
+	return a.bus.requeue(a.group, a.message, requeueAfter)
+}
+
+func (a acknowledger) Term(_ context.Context) error {
+	return nil
+}
+
+// Shutdown closes the Bus, waiting for in-flight handlers to finish or the given context to be done.
+func (b *Bus) Shutdown(ctx context.Context) error {
+	b.close()
+	b.mu.RLock()
+	started := b.startedBus
+	b.mu.RUnlock()
+	if !started {
+		return nil
+	}
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close rejects further writes and stops the message buffer listening job.
+func (b *Bus) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closedBus {
+		return
+	}
+	b.closedBus = true
+	for timer := range b.delayed {
+		timer.Stop()
+		delete(b.delayed, timer)
+	}
+	close(b.done)
+}
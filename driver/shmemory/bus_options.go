@@ -0,0 +1,55 @@
+package shmemory
+
+// DefaultBufferCapacity default number of messages a Bus holds before applying its WritePolicy.
+const DefaultBufferCapacity = 1024
+
+// WritePolicy defines the behaviour of Bus writes when its message buffer is full.
+type WritePolicy uint8
+
+const (
+	// WritePolicyBlock blocks writes until buffer space is available, the write context is canceled or the Bus is
+	// closed.
+	WritePolicyBlock WritePolicy = iota
+	// WritePolicyDropOldest discards the oldest buffered message to make room for the written one.
+	WritePolicyDropOldest
+	// WritePolicyFailFast rejects writes with ErrBusFull.
+	WritePolicyFailFast
+)
+
+type busOptions struct {
+	bufferCapacity int
+	writePolicy    WritePolicy
+}
+
+// BusOption enables configuration of a Bus instance.
+type BusOption interface {
+	apply(*busOptions)
+}
+
+type bufferCapacityOption struct {
+	Capacity int
+}
+
+func (o bufferCapacityOption) apply(opts *busOptions) {
+	if o.Capacity > 0 {
+		opts.bufferCapacity = o.Capacity
+	}
+}
+
+// WithBufferCapacity sets the number of messages a Bus holds before applying its WritePolicy.
+func WithBufferCapacity(n int) BusOption {
+	return bufferCapacityOption{Capacity: n}
+}
+
+type writePolicyOption struct {
+	Policy WritePolicy
+}
+
+func (o writePolicyOption) apply(opts *busOptions) {
+	opts.writePolicy = o.Policy
+}
+
+// WithWritePolicy sets the behaviour of Bus writes when its message buffer is full.
+func WithWritePolicy(p WritePolicy) BusOption {
+	return writePolicyOption{Policy: p}
+}
@@ -2,10 +2,12 @@ package shmemory
 
 import (
 	"context"
+	"errors"
 	"runtime"
 	"testing"
 	"time"
 
+	"github.com/neutrinocorp/streams"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,3 +25,206 @@ func TestBus_Start(t *testing.T) {
 	time.Sleep(time.Millisecond * 100)
 	assert.Equal(t, 2, runtime.NumGoroutine())
 }
+
+func TestBus_WritePolicy(t *testing.T) {
+	t.Run("Fail fast", func(t *testing.T) {
+		b := NewBus(0, WithBufferCapacity(1), WithWritePolicy(WritePolicyFailFast))
+		// mark as started without running the listening job, so the buffer fills up
+		b.startedBus = true
+		assert.NoError(t, b.write(context.Background(), streams.Message{ID: "1"}))
+		assert.ErrorIs(t, b.write(context.Background(), streams.Message{ID: "2"}), ErrBusFull)
+	})
+
+	t.Run("Drop oldest", func(t *testing.T) {
+		b := NewBus(0, WithBufferCapacity(2), WithWritePolicy(WritePolicyDropOldest))
+		b.startedBus = true
+		for _, id := range []string{"1", "2", "3"} {
+			assert.NoError(t, b.write(context.Background(), streams.Message{ID: id}))
+		}
+		assert.Equal(t, "2", (<-b.messageBuffer).ID)
+		assert.Equal(t, "3", (<-b.messageBuffer).ID)
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		b := NewBus(0, WithBufferCapacity(1))
+		b.startedBus = true
+		assert.NoError(t, b.write(context.Background(), streams.Message{ID: "1"}))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		assert.ErrorIs(t, b.write(ctx, streams.Message{ID: "2"}), context.DeadlineExceeded)
+
+		errWrite := make(chan error, 1)
+		go func() {
+			errWrite <- b.write(context.Background(), streams.Message{ID: "3"})
+		}()
+		time.Sleep(time.Millisecond * 10)
+		// blocked writers are released once the bus gets closed
+		b.close()
+		assert.ErrorIs(t, <-errWrite, ErrBusClosed)
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		// pushes of non-blocking policies are rejected too once the bus gets closed
+		for _, policy := range []WritePolicy{WritePolicyFailFast, WritePolicyDropOldest} {
+			b := NewBus(0, WithBufferCapacity(1), WithWritePolicy(policy))
+			b.startedBus = true
+			b.close()
+			assert.ErrorIs(t, b.push(context.Background(), streams.Message{ID: "1"}), ErrBusClosed)
+		}
+	})
+}
+
+func TestBus_Close(t *testing.T) {
+	b := NewBus(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	handlerStarted := make(chan struct{})
+	release := make(chan struct{})
+	b.registerHandler(streams.ReaderTask{
+		Stream: "foo-stream",
+		HandlerFunc: func(_ context.Context, _ streams.Message) error {
+			close(handlerStarted)
+			<-release
+			return nil
+		},
+		Timeout: time.Second,
+	})
+	b.start(ctx)
+	assert.NoError(t, b.write(context.Background(), streams.Message{Stream: "foo-stream"}))
+	<-handlerStarted
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		// writes after closing fail instead of panicking
+		return errors.Is(b.write(context.Background(), streams.Message{Stream: "foo-stream"}), ErrBusClosed)
+	}, time.Second, time.Millisecond*10)
+	select {
+	case <-b.stopped:
+		assert.Fail(t, "bus stopped with in-flight handlers")
+	default:
+	}
+	close(release)
+	select {
+	case <-b.stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "bus did not stop")
+	}
+	time.Sleep(time.Millisecond * 10)
+}
+
+func TestBus_Shutdown(t *testing.T) {
+	b := NewBus(0)
+	release := make(chan struct{})
+	handlerStarted := make(chan struct{})
+	r := NewReader(b)
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+	assert.NoError(t, r.ExecuteTask(baseCtx, streams.ReaderTask{
+		Stream: "foo-stream",
+		HandlerFunc: func(_ context.Context, _ streams.Message) error {
+			close(handlerStarted)
+			<-release
+			return nil
+		},
+		Timeout: time.Second,
+	}))
+	w := NewWriter(b)
+	assert.NoError(t, w.Write(context.Background(), streams.Message{Stream: "foo-stream"}))
+	<-handlerStarted
+
+	// in-flight handlers are waited for until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.ErrorIs(t, r.Shutdown(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, w.Write(context.Background(), streams.Message{Stream: "foo-stream"}), ErrBusClosed)
+
+	close(release)
+	assert.NoError(t, w.Shutdown(context.Background()))
+	// a bus never started is closed right away
+	assert.NoError(t, NewBus(0).Shutdown(context.Background()))
+	cancelBase()
+	time.Sleep(time.Millisecond * 10)
+}
+
+func TestBus_Semaphore(t *testing.T) {
+	b := NewBus(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-b.stopped
+		// let the context watching job exit
+		time.Sleep(time.Millisecond * 10)
+	}()
+	received := make(chan string, 4)
+	for _, group := range []string{"group-a", "group-b"} {
+		b.registerHandler(streams.ReaderTask{
+			Stream: "foo-stream",
+			Group:  group,
+			HandlerFunc: func(_ context.Context, message streams.Message) error {
+				received <- message.ID
+				return nil
+			},
+			Timeout: time.Second,
+		})
+	}
+	b.start(ctx)
+
+	// messages without handlers must not hold slots
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, b.write(context.Background(), streams.Message{Stream: "bar-stream"}))
+	}
+	assert.NoError(t, b.write(context.Background(), streams.Message{ID: "1", Stream: "foo-stream"}))
+	assert.NoError(t, b.write(context.Background(), streams.Message{ID: "2", Stream: "foo-stream"}))
+	for i := 0; i < 4; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			assert.Fail(t, "message not delivered")
+			return
+		}
+	}
+}
+
+func TestBus_Delayed(t *testing.T) {
+	b := NewBus(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-b.stopped
+		time.Sleep(time.Millisecond * 10)
+	}()
+	received := make(chan string, 3)
+	b.registerHandler(streams.ReaderTask{
+		Stream: "foo-stream",
+		HandlerFunc: func(_ context.Context, message streams.Message) error {
+			received <- message.ID
+			return nil
+		},
+		Timeout: time.Second,
+	})
+	b.start(ctx)
+
+	deliverAt := func(d time.Duration) string {
+		return time.Now().Add(d).UTC().Format(time.RFC3339Nano)
+	}
+	assert.NoError(t, b.write(context.Background(), streams.Message{ID: "1", Stream: "foo-stream",
+		DeliverAt: deliverAt(time.Millisecond * 50)}))
+	assert.NoError(t, b.write(context.Background(), streams.Message{ID: "2", Stream: "foo-stream",
+		DeliverAt: deliverAt(-time.Second)}))
+	assert.NoError(t, b.write(context.Background(), streams.Message{ID: "3", Stream: "foo-stream",
+		DeliverAt: deliverAt(time.Hour)}))
+	assert.Equal(t, "2", <-received)
+	select {
+	case id := <-received:
+		assert.Equal(t, "1", id)
+	case <-time.After(time.Second):
+		assert.Fail(t, "delayed message not received")
+	}
+
+	// pending delayed messages are discarded on close
+	b.close()
+	b.mu.RLock()
+	assert.Empty(t, b.delayed)
+	b.mu.RUnlock()
+	assert.ErrorIs(t, b.write(context.Background(), streams.Message{Stream: "foo-stream",
+		DeliverAt: deliverAt(time.Hour)}), ErrBusClosed)
+}
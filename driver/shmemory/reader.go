@@ -11,7 +11,10 @@ type Reader struct {
 	b *Bus
 }
 
-var _ streams.Reader = &Reader{}
+var (
+	_ streams.Reader     = &Reader{}
+	_ streams.Shutdowner = &Reader{}
+)
 
 // NewReader allocates a new Reader ready to interact with the given Bus
 func NewReader(b *Bus) *Reader {
@@ -24,3 +27,8 @@ func (l *Reader) ExecuteTask(ctx context.Context, t streams.ReaderTask) error {
 	l.b.start(ctx)
 	return nil
 }
+
+// Shutdown closes the internal in-memory Bus, waiting for in-flight handlers to finish.
+func (l *Reader) Shutdown(ctx context.Context) error {
+	return l.b.Shutdown(ctx)
+}
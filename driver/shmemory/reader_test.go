@@ -2,13 +2,17 @@ package shmemory_test
 
 import (
 	"context"
+	"errors"
 	"runtime"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/neutrinocorp/streams"
 	"github.com/neutrinocorp/streams/driver/shmemory"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReader_ExecuteTask(t *testing.T) {
@@ -31,3 +35,253 @@ func TestReader_ExecuteTask(t *testing.T) {
 	// ensure goroutines were de-scheduled
 	assert.Equal(t, 2, runtime.NumGoroutine())
 }
+
+func TestReader_ExecuteTask_Groups(t *testing.T) {
+	bus := shmemory.NewBus(0)
+	reader := shmemory.NewReader(bus)
+	writer := shmemory.NewWriter(bus)
+	baseCtx, cancel := context.WithCancel(context.Background())
+
+	mu := sync.Mutex{}
+	received := map[string][]string{}
+	newHandler := func(task string) streams.ReaderHandleFunc {
+		return func(_ context.Context, message streams.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[task] = append(received[task], message.ID)
+			return nil
+		}
+	}
+	for _, task := range []struct{ name, group, nodeID string }{
+		{"a1", "group-a", ""}, {"a2", "group-a", ""}, {"b1", "group-b", ""}, {"u1", "", ""}, {"u2", "", ""},
+		// concurrent copies of an ungrouped node
+		{"n1", "", "node-1"}, {"n2", "", "node-1"},
+	} {
+		err := reader.ExecuteTask(baseCtx, streams.ReaderTask{
+			Stream:      "foo-stream",
+			Group:       task.group,
+			NodeID:      task.nodeID,
+			HandlerFunc: newHandler(task.name),
+			Timeout:     time.Second,
+		})
+		assert.NoError(t, err)
+	}
+	for _, id := range []string{"1", "2", "3", "4"} {
+		assert.NoError(t, writer.Write(context.Background(), streams.Message{ID: id, Stream: "foo-stream"}))
+	}
+
+	count := func(tasks ...string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, task := range tasks {
+			n += len(received[task])
+		}
+		return n
+	}
+	assert.Eventually(t, func() bool {
+		return count("a1", "a2") == 4 && count("b1") == 4 && count("u1") == 4 && count("u2") == 4 &&
+			count("n1", "n2") == 4
+	}, time.Second, time.Millisecond*10)
+	// messages are load-balanced across the tasks of a group
+	assert.Equal(t, 2, count("a1"))
+	assert.Equal(t, 2, count("a2"))
+	assert.Equal(t, 2, count("n1"))
+	assert.Equal(t, 2, count("n2"))
+
+	cancel()
+	// ensure goroutines were de-scheduled
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 2, runtime.NumGoroutine())
+}
+
+func TestReader_ExecuteTask_PartitionKey(t *testing.T) {
+	bus := shmemory.NewBus(0)
+	reader := shmemory.NewReader(bus)
+	writer := shmemory.NewWriter(bus)
+	baseCtx, cancel := context.WithCancel(context.Background())
+
+	mu := sync.Mutex{}
+	received := map[string][]string{}
+	inFlight := map[string]bool{}
+	overlapped := false
+	for i := 0; i < 3; i++ {
+		err := reader.ExecuteTask(baseCtx, streams.ReaderTask{
+			Stream: "foo-stream",
+			Group:  "group-a",
+			HandlerFunc: func(_ context.Context, message streams.Message) error {
+				mu.Lock()
+				overlapped = overlapped || inFlight[message.PartitionKey]
+				inFlight[message.PartitionKey] = true
+				mu.Unlock()
+				// later messages would overtake earlier ones if they were not ordered
+				time.Sleep(time.Millisecond * time.Duration(len(message.Data)))
+				mu.Lock()
+				defer mu.Unlock()
+				inFlight[message.PartitionKey] = false
+				received[message.PartitionKey] = append(received[message.PartitionKey], message.ID)
+				return nil
+			},
+			Timeout: time.Second,
+		})
+		assert.NoError(t, err)
+	}
+	expected := map[string][]string{"foo": {}, "bar": {}}
+	for i := 0; i < 10; i++ {
+		for _, key := range []string{"foo", "bar"} {
+			id := key + "-" + strconv.Itoa(i)
+			expected[key] = append(expected[key], id)
+			assert.NoError(t, writer.Write(context.Background(), streams.Message{
+				ID:           id,
+				Stream:       "foo-stream",
+				Data:         make([]byte, 10-i),
+				PartitionKey: key,
+			}))
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received["foo"]) == 10 && len(received["bar"]) == 10
+	}, time.Second, time.Millisecond*10)
+	mu.Lock()
+	assert.Equal(t, expected, received)
+	assert.False(t, overlapped)
+	mu.Unlock()
+
+	cancel()
+	// ensure goroutines were de-scheduled
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 2, runtime.NumGoroutine())
+}
+
+func TestReader_ExecuteTask_Nack(t *testing.T) {
+	bus := shmemory.NewBus(0)
+	reader := shmemory.NewReader(bus)
+	writer := shmemory.NewWriter(bus)
+	baseCtx, cancel := context.WithCancel(context.Background())
+
+	mu := sync.Mutex{}
+	received := map[string]int{}
+	for _, group := range []string{"group-a", "group-b"} {
+		group := group
+		err := reader.ExecuteTask(baseCtx, streams.ReaderTask{
+			Stream: "foo-stream",
+			Group:  group,
+			HandlerFunc: func(ctx context.Context, message streams.Message) error {
+				mu.Lock()
+				received[group]++
+				attempt := received[group]
+				mu.Unlock()
+				ack, ok := streams.AcknowledgerFromContext(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, ack, message.Acknowledger)
+				if group == "group-a" && attempt == 1 {
+					assert.NoError(t, message.Acknowledger.Nack(ctx, time.Millisecond*20))
+					assert.ErrorIs(t, message.Acknowledger.Ack(ctx), streams.ErrMessageSettled)
+					return nil
+				}
+				return message.Acknowledger.Ack(ctx)
+			},
+			Timeout: time.Second,
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Write(context.Background(), streams.Message{ID: "1", Stream: "foo-stream"}))
+
+	// rejected messages are delivered again to the same group only
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return received["group-a"] == 2
+	}, time.Second, time.Millisecond*5)
+	time.Sleep(time.Millisecond * 20)
+	mu.Lock()
+	assert.Equal(t, 1, received["group-b"])
+	mu.Unlock()
+
+	cancel()
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 2, runtime.NumGoroutine())
+}
+
+type fooMessage struct {
+	Foo string `json:"foo"`
+}
+
+func TestReader_ExecuteTask_Batch(t *testing.T) {
+	bus := shmemory.NewBus(0)
+	hub := streams.NewHub(
+		streams.WithWriter(shmemory.NewWriter(bus)),
+		streams.WithReader(shmemory.NewReader(bus)))
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	hub.RegisterStreamByString("foo-dlq", streams.StreamMetadata{
+		Stream: "foo-dlq",
+	})
+
+	errPoison := errors.New("poison message")
+	mu := sync.Mutex{}
+	var batches [][]string
+	attempts := map[string]int{}
+	require.NoError(t, hub.Read(fooMessage{}, streams.WithGroup("foo-group"),
+		streams.WithDeadLetterStream("foo-dlq"),
+		streams.WithRetryInitialInterval(time.Millisecond),
+		streams.WithRetryMaxInterval(time.Millisecond),
+		streams.WithBatchHandlerFunc(func(_ context.Context, messages []streams.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			batch := make([]string, 0, len(messages))
+			errs := streams.BatchItemErrors{}
+			for i, msg := range messages {
+				assert.Equal(t, "foo-group", msg.GroupName)
+				foo := msg.DecodedData.(fooMessage).Foo
+				batch = append(batch, foo)
+				attempts[foo]++
+				if foo == "poison" {
+					errs[i] = streams.Permanent(errPoison)
+				} else if foo == "flaky" && attempts[foo] == 1 {
+					errs[i] = errors.New("generic error")
+				}
+			}
+			batches = append(batches, batch)
+			return errs
+		}, 3, time.Millisecond*50)))
+	deadLetters := make(chan streams.Message, 1)
+	hub.ReadByStreamKey("foo-dlq", streams.WithHandlerFunc(func(_ context.Context, message streams.Message) error {
+		deadLetters <- message
+		return nil
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	hub.Start(ctx)
+
+	_, err := hub.WriteBatch(context.Background(), fooMessage{Foo: "foo"}, fooMessage{Foo: "flaky"},
+		fooMessage{Foo: "poison"})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-deadLetters:
+		assert.Equal(t, "poison message", msg.Headers[streams.HeaderDeadLetterError])
+	case <-time.After(time.Second):
+		t.Fatal("dead-letter message not received")
+	}
+	// failed messages are retried individually
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts["flaky"] == 2
+	}, time.Second, time.Millisecond*5)
+	mu.Lock()
+	require.Len(t, batches, 2)
+	assert.ElementsMatch(t, []string{"foo", "flaky", "poison"}, batches[0])
+	assert.Equal(t, []string{"flaky"}, batches[1])
+	assert.Equal(t, 1, attempts["foo"])
+	assert.Equal(t, 1, attempts["poison"])
+	mu.Unlock()
+
+	cancel()
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 2, runtime.NumGoroutine())
+}
@@ -7,15 +7,24 @@ import (
 	"github.com/neutrinocorp/streams"
 )
 
-// ErrBusNotStarted The in-memory bus has not been started
-var ErrBusNotStarted = errors.New("streams: In-memory bus has not been started")
+var (
+	// ErrBusNotStarted The in-memory bus has not been started
+	ErrBusNotStarted = errors.New("streams: In-memory bus has not been started")
+	// ErrBusClosed The in-memory bus has been closed
+	ErrBusClosed = errors.New("streams: In-memory bus has been closed")
+	// ErrBusFull The in-memory bus buffer is full and its write policy rejects new messages
+	ErrBusFull = errors.New("streams: In-memory bus buffer is full")
+)
 
 // Writer is the streams.Writer in-memory implementation
 type Writer struct {
 	b *Bus
 }
 
-var _ streams.Writer = &Writer{}
+var (
+	_ streams.Writer     = &Writer{}
+	_ streams.Shutdowner = &Writer{}
+)
 
 // NewWriter allocates a new Writer ready to be used with the given Bus
 func NewWriter(b *Bus) *Writer {
@@ -28,13 +37,15 @@ func (p *Writer) Write(ctx context.Context, message streams.Message) error {
 }
 
 // WriteBatch pushes the given set of messages into the internal in-memory Bus
-func (p *Writer) WriteBatch(ctx context.Context, messages ...streams.Message) (published uint32, err error) {
-	for _, msg := range messages {
-		if errPub := p.b.write(ctx, msg); errPub != nil {
-			err = errPub
-			continue
-		}
-		published++
+func (p *Writer) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	for i, msg := range messages {
+		res[i].Err = p.b.write(ctx, msg)
 	}
-	return
+	return res, res.Err()
+}
+
+// Shutdown closes the internal in-memory Bus, waiting for in-flight handlers to finish.
+func (p *Writer) Shutdown(ctx context.Context) error {
+	return p.b.Shutdown(ctx)
 }
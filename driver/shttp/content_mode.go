@@ -0,0 +1,37 @@
+package shttp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/cloudevents"
+)
+
+// ContentMode CloudEvents HTTP protocol binding content mode used to encode messages.
+type ContentMode uint8
+
+const (
+	// BinaryContentMode encodes message attributes as ce-prefixed HTTP headers and message data as HTTP body.
+	BinaryContentMode ContentMode = iota
+	// StructuredContentMode encodes the whole message as a JSON CloudEvent in HTTP body.
+	StructuredContentMode
+)
+
+// encodeMessage encodes the given message into HTTP headers and body using the given content mode.
+func encodeMessage(mode ContentMode, message streams.Message) (http.Header, io.Reader, error) {
+	if mode == StructuredContentMode {
+		data, err := cloudevents.MarshalStructured(message)
+		if err != nil {
+			return nil, nil, err
+		}
+		return http.Header{"Content-Type": {cloudevents.ContentTypeStructuredJSON}}, bytes.NewReader(data), nil
+	}
+
+	header, data, err := cloudevents.EncodeBinary(message)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, bytes.NewReader(data), nil
+}
@@ -0,0 +1,5 @@
+// Package shttp is the HTTP implementation for Streamhub-based programs.
+//
+// Messages are transported as CNCF CloudEvents using either HTTP binary or JSON structured content modes, enabling
+// interactions with webhook-based services and between Hub instances without a message broker.
+package shttp
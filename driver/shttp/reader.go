@@ -0,0 +1,297 @@
+package shttp
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/cloudevents"
+)
+
+// ReaderConfig Reader configuration.
+type ReaderConfig struct {
+	// MaxBodySize maximum size in bytes of incoming HTTP request bodies.
+	MaxBodySize int64
+}
+
+// DefaultReaderConfig default Reader configuration.
+var DefaultReaderConfig = ReaderConfig{
+	MaxBodySize: 1 << 20, // 1 MiB
+}
+
+// Reader is the streams.Reader HTTP implementation.
+//
+// Reader is an http.Handler which converts incoming CloudEvents (either binary or structured content modes) into
+// executions of the stream-reading tasks registered for the message stream. Each message is handled by a single task
+// of every reader group (round-robin); tasks without group are grouped by the ReaderNode they were forked from.
+// Responds with:
+//
+// - 204 No Content if every task handler succeeded.
+//
+// - 400 Bad Request if the request is not a valid CloudEvent.
+//
+// - 404 Not Found if no task was registered for the message stream.
+//
+// - 422 Unprocessable Entity if a task handler failed with a streams.Permanent error.
+//
+// - 500 Internal Server Error if a task handler failed.
+//
+// - 503 Service Unavailable if the Reader or the streams.Hub is shutting down or the request was canceled while
+// waiting for a handler slot.
+type Reader struct {
+	config ReaderConfig
+
+	mu sync.RWMutex
+	// key: Stream name | value: List of reader groups
+	groups   map[string][]*readerGroup
+	closed   bool
+	inFlight sync.WaitGroup
+}
+
+// readerGroup is a set of tasks sharing a consumer group. Each message is delivered to a single task of the group.
+type readerGroup struct {
+	key   readerGroupKey
+	tasks []*readerTask
+	next  uint32
+}
+
+// readerGroupKey identifies a reader group within a stream. Tasks without group are keyed by their node.
+type readerGroupKey struct {
+	group  string
+	nodeID string
+}
+
+func newReaderGroupKey(task streams.ReaderTask) readerGroupKey {
+	if task.Group != "" {
+		return readerGroupKey{group: task.Group}
+	}
+	return readerGroupKey{nodeID: task.NodeID}
+}
+
+// schedule retrieves the task to deliver the next message to using round-robin.
+//
+// Requires the Reader lock (read or write) to be held.
+func (g *readerGroup) schedule() *readerTask {
+	next := atomic.AddUint32(&g.next, 1)
+	return g.tasks[(next-1)%uint32(len(g.tasks))]
+}
+
+type readerTask struct {
+	task streams.ReaderTask
+	sem  chan struct{}
+}
+
+var (
+	_ streams.Reader     = &Reader{}
+	_ streams.Shutdowner = &Reader{}
+	_ http.Handler       = &Reader{}
+)
+
+// NewReader allocates a new Reader ready to be used.
+func NewReader(cfg ReaderConfig) *Reader {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultReaderConfig.MaxBodySize
+	}
+	return &Reader{
+		config: cfg,
+		groups: map[string][]*readerGroup{},
+	}
+}
+
+// ExecuteTask registers the given task, so it gets executed when a message of the task stream is received.
+//
+// The task gets unregistered once the given context is canceled.
+func (r *Reader) ExecuteTask(ctx context.Context, task streams.ReaderTask) error {
+	if task.HandlerFunc == nil {
+		return streams.ErrMissingReaderHandler
+	}
+	poolSize := task.MaxHandlerPoolSize
+	if poolSize <= 0 {
+		poolSize = streams.DefaultMaxHandlerPoolSize
+	}
+	t := &readerTask{
+		task: task,
+		sem:  make(chan struct{}, poolSize),
+	}
+
+	r.register(t)
+	go func() {
+		<-ctx.Done()
+		r.unregister(t)
+	}()
+	return nil
+}
+
+// register joins the given task into its reader group. Tasks without group and without node are registered as
+// single-task groups, so each one receives every message of the stream.
+func (r *Reader) register(t *readerTask) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := newReaderGroupKey(t.task)
+	groups := r.groups[t.task.Stream]
+	if key != (readerGroupKey{}) {
+		for _, g := range groups {
+			if g.key == key {
+				g.tasks = append(g.tasks, t)
+				return
+			}
+		}
+	}
+	r.groups[t.task.Stream] = append(groups, &readerGroup{
+		key:   key,
+		tasks: []*readerTask{t},
+	})
+}
+
+func (r *Reader) unregister(t *readerTask) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	groups := r.groups[t.task.Stream]
+	for i, g := range groups {
+		for j, registered := range g.tasks {
+			if registered != t {
+				continue
+			}
+			g.tasks = append(g.tasks[:j:j], g.tasks[j+1:]...)
+			if len(g.tasks) == 0 {
+				groups = append(groups[:i:i], groups[i+1:]...)
+			}
+			break
+		}
+	}
+	if len(groups) == 0 {
+		delete(r.groups, t.task.Stream)
+		return
+	}
+	r.groups[t.task.Stream] = groups
+}
+
+// Shutdown stops accepting new messages and waits for in-flight handlers to finish.
+func (r *Reader) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		r.inFlight.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ServeHTTP executes the tasks registered for the stream of the incoming CloudEvent (stream extension attribute).
+func (r *Reader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.serve(w, req, "")
+}
+
+// Handler retrieves an http.Handler executing the tasks registered for the given stream, regardless of the stream
+// extension attribute of incoming CloudEvents. Useful when receiving messages from non-streams producers.
+func (r *Reader) Handler(stream string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.serve(w, req, stream)
+	})
+}
+
+func (r *Reader) serve(w http.ResponseWriter, req *http.Request, stream string) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, r.config.MaxBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	message, err := cloudevents.DecodeHTTP(req.Header, body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if stream != "" {
+		message.Stream = stream
+	}
+
+	r.mu.RLock()
+	if r.closed {
+		r.mu.RUnlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	groups := r.groups[message.Stream]
+	if len(groups) == 0 {
+		r.mu.RUnlock()
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	tasks := make([]*readerTask, 0, len(groups))
+	for _, g := range groups {
+		tasks = append(tasks, g.schedule())
+	}
+	r.inFlight.Add(1)
+	r.mu.RUnlock()
+	defer r.inFlight.Done()
+
+	w.WriteHeader(statusCode(r.execute(req.Context(), tasks, message)))
+}
+
+// execute runs the given tasks concurrently with a copy of the message, retrieving the result of each task.
+func (r *Reader) execute(ctx context.Context, tasks []*readerTask, message streams.Message) []error {
+	errs := make([]error, len(tasks))
+	wg := sync.WaitGroup{}
+	wg.Add(len(tasks))
+	for i, t := range tasks {
+		go func(i int, t *readerTask) {
+			defer wg.Done()
+			errs[i] = t.execute(ctx, message)
+		}(i, t)
+	}
+	wg.Wait()
+	return errs
+}
+
+func (t *readerTask) execute(ctx context.Context, message streams.Message) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case t.sem <- struct{}{}:
+	}
+	defer func() { <-t.sem }()
+
+	var cancel context.CancelFunc
+	if t.task.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.task.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	return t.task.HandlerFunc(ctx, message)
+}
+
+// statusCode maps the results of task handlers into an HTTP status code. Permanent failures are reported only if
+// no task failed with a transient error, so senders do not skip retries required by other tasks.
+func statusCode(errs []error) int {
+	code := http.StatusNoContent
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, context.Canceled), errors.Is(err, streams.ErrHubClosed):
+			return http.StatusServiceUnavailable
+		case streams.IsPermanent(err):
+			code = http.StatusUnprocessableEntity
+		default:
+			return http.StatusInternalServerError
+		}
+	}
+	return code
+}
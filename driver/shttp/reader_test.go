@@ -0,0 +1,301 @@
+package shttp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/driver/shttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_ExecuteTask(t *testing.T) {
+	reader := shttp.NewReader(shttp.DefaultReaderConfig)
+	err := reader.ExecuteTask(context.Background(), streams.ReaderTask{Stream: "foo-stream"})
+	assert.ErrorIs(t, err, streams.ErrMissingReaderHandler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = reader.ExecuteTask(ctx, streams.ReaderTask{
+		Stream: "foo-stream",
+		HandlerFunc: func(_ context.Context, _ streams.Message) error {
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(reader)
+	defer srv.Close()
+	writer := shttp.NewWriter(srv.Client(), shttp.WriterConfig{
+		Endpoints: map[string]string{"foo-stream": srv.URL},
+	})
+	assert.NoError(t, writer.Write(context.Background(), newTestMessage("1", "foo-stream")))
+
+	// task gets unregistered once its context is canceled
+	cancel()
+	assert.Eventually(t, func() bool {
+		return errors.Is(writer.Write(context.Background(), newTestMessage("2", "foo-stream")),
+			shttp.ErrUnexpectedStatusCode)
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestReader_ServeHTTP(t *testing.T) {
+	mu := sync.Mutex{}
+	received := map[string][]string{}
+	reader := shttp.NewReader(shttp.ReaderConfig{MaxBodySize: 128})
+	newHandler := func(group string) streams.ReaderHandleFunc {
+		return func(_ context.Context, message streams.Message) error {
+			mu.Lock()
+			received[group] = append(received[group], message.ID)
+			mu.Unlock()
+			switch message.ID {
+			case "failing":
+				return errors.New("generic error")
+			case "permanent":
+				return streams.Permanent(errors.New("generic error"))
+			}
+			return nil
+		}
+	}
+	for _, group := range []string{"group-a", "group-b"} {
+		err := reader.ExecuteTask(context.Background(), streams.ReaderTask{
+			Stream:      "foo-stream",
+			Group:       group,
+			HandlerFunc: newHandler(group),
+		})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		Name    string
+		Method  string
+		Header  http.Header
+		Body    string
+		ExpCode int
+	}{
+		{
+			Name:    "Invalid method",
+			Method:  http.MethodGet,
+			ExpCode: http.StatusMethodNotAllowed,
+		},
+		{
+			Name:    "Invalid event",
+			Header:  http.Header{"Ce-Id": {"1"}},
+			ExpCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Body too large",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Ce-Stream": {"foo-stream"}},
+			Body:    strings.Repeat("a", 129),
+			ExpCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Unknown stream",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Ce-Stream": {"bar-stream"}},
+			ExpCode: http.StatusNotFound,
+		},
+		{
+			Name: "Binary",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"binary"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Ce-Stream": {"foo-stream"}},
+			Body:    "foo",
+			ExpCode: http.StatusNoContent,
+		},
+		{
+			Name:   "Structured",
+			Header: http.Header{"Content-Type": {"application/cloudevents+json"}},
+			Body: `{"specversion":"1.0","id":"structured","source":"foo","type":"bar",
+				"stream":"foo-stream"}`,
+			ExpCode: http.StatusNoContent,
+		},
+		{
+			Name: "Failing handler",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"failing"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Ce-Stream": {"foo-stream"}},
+			ExpCode: http.StatusInternalServerError,
+		},
+		{
+			Name: "Permanent failing handler",
+			Header: http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"permanent"}, "Ce-Source": {"foo"},
+				"Ce-Type": {"bar"}, "Ce-Stream": {"foo-stream"}},
+			ExpCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			method := tt.Method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/", bytes.NewBufferString(tt.Body))
+			for k, v := range tt.Header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			reader.ServeHTTP(rec, req)
+			assert.Equal(t, tt.ExpCode, rec.Code)
+		})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// every group receives its own copy of the message
+	exp := []string{"binary", "structured", "failing", "permanent"}
+	assert.Equal(t, map[string][]string{"group-a": exp, "group-b": exp}, received)
+}
+
+func TestReader_Handler(t *testing.T) {
+	messages := make(chan streams.Message, 1)
+	reader := shttp.NewReader(shttp.DefaultReaderConfig)
+	err := reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream: "foo-stream",
+		HandlerFunc: func(_ context.Context, message streams.Message) error {
+			messages <- message
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	// event from a non-streams producer (no stream extension attribute)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/foo", nil)
+	req.Header = http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"saas"},
+		"Ce-Type": {"saas.created"}}
+	rec := httptest.NewRecorder()
+	reader.Handler("foo-stream").ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "foo-stream", (<-messages).Stream)
+}
+
+func TestReader_Shutdown(t *testing.T) {
+	handlerStarted := make(chan struct{})
+	reader := shttp.NewReader(shttp.DefaultReaderConfig)
+	err := reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream: "foo-stream",
+		HandlerFunc: func(_ context.Context, _ streams.Message) error {
+			close(handlerStarted)
+			time.Sleep(time.Millisecond * 50)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(reader)
+	defer srv.Close()
+	writer := shttp.NewWriter(srv.Client(), shttp.WriterConfig{
+		Endpoints: map[string]string{"foo-stream": srv.URL},
+	})
+
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- writer.Write(context.Background(), newTestMessage("1", "foo-stream"))
+	}()
+	<-handlerStarted
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, reader.Shutdown(ctx), context.DeadlineExceeded)
+	assert.NoError(t, reader.Shutdown(context.Background()))
+	// in-flight message is processed while new ones are rejected
+	assert.NoError(t, <-writeErr)
+	assert.ErrorIs(t, writer.Write(context.Background(), newTestMessage("2", "foo-stream")),
+		shttp.ErrUnexpectedStatusCode)
+}
+
+func TestReader_ServeHTTP_ConcurrencyLevel(t *testing.T) {
+	reader := shttp.NewReader(shttp.DefaultReaderConfig)
+	hub := streams.NewHub(streams.WithReader(reader))
+	hub.ReaderBehaviours = nil
+	mu := sync.Mutex{}
+	received := map[string]int{}
+	newHandler := func(node string) streams.ReaderHandleFunc {
+		return func(_ context.Context, _ streams.Message) error {
+			mu.Lock()
+			received[node]++
+			mu.Unlock()
+			return nil
+		}
+	}
+	hub.ReadByStreamKey("foo-stream", streams.WithConcurrencyLevel(3),
+		streams.WithHandlerFunc(newHandler("ungrouped")))
+	hub.ReadByStreamKey("foo-stream", streams.WithConcurrencyLevel(3), streams.WithGroup("group-a"),
+		streams.WithHandlerFunc(newHandler("group-a")))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub.Start(ctx)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header = http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"foo"},
+			"Ce-Type": {"bar"}, "Ce-Stream": {"foo-stream"}}
+		rec := httptest.NewRecorder()
+		reader.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// concurrent copies of a node share its messages
+	assert.Equal(t, map[string]int{"ungrouped": 3, "group-a": 3}, received)
+}
+
+func TestReader_ServeHTTP_Group(t *testing.T) {
+	mu := sync.Mutex{}
+	received := map[string][]string{}
+	reader := shttp.NewReader(shttp.DefaultReaderConfig)
+	for _, node := range []string{"node-a", "node-b"} {
+		node := node
+		err := reader.ExecuteTask(context.Background(), streams.ReaderTask{
+			Stream: "foo-stream",
+			Group:  "group-a",
+			NodeID: node,
+			HandlerFunc: func(_ context.Context, message streams.Message) error {
+				mu.Lock()
+				received[node] = append(received[node], message.ID)
+				mu.Unlock()
+				return nil
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	for _, id := range []string{"1", "2", "3", "4"} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header = http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {id}, "Ce-Source": {"foo"},
+			"Ce-Type": {"bar"}, "Ce-Stream": {"foo-stream"}}
+		rec := httptest.NewRecorder()
+		reader.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// nodes of a group take turns
+	assert.Equal(t, map[string][]string{"node-a": {"1", "3"}, "node-b": {"2", "4"}}, received)
+}
+
+func TestReader_ServeHTTP_HubClosed(t *testing.T) {
+	reader := shttp.NewReader(shttp.DefaultReaderConfig)
+	err := reader.ExecuteTask(context.Background(), streams.ReaderTask{
+		Stream: "foo-stream",
+		HandlerFunc: func(_ context.Context, _ streams.Message) error {
+			return streams.ErrHubClosed
+		},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header = http.Header{"Ce-Specversion": {"1.0"}, "Ce-Id": {"1"}, "Ce-Source": {"foo"},
+		"Ce-Type": {"bar"}, "Ce-Stream": {"foo-stream"}}
+	rec := httptest.NewRecorder()
+	reader.ServeHTTP(rec, req)
+	// senders retry once another instance takes over
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,102 @@
+package shttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/neutrinocorp/streams"
+)
+
+var (
+	// ErrMissingEndpoint no endpoint was configured for the stream of a message.
+	ErrMissingEndpoint = errors.New("streams: Missing HTTP endpoint for stream")
+	// ErrUnexpectedStatusCode the endpoint responded with a non-2xx HTTP status code.
+	ErrUnexpectedStatusCode = errors.New("streams: Unexpected HTTP status code")
+)
+
+// WriterConfig Writer configuration.
+type WriterConfig struct {
+	// Endpoints destination URL of each stream (key: Stream name | value: URL).
+	Endpoints map[string]string
+	// ContentMode CloudEvents content mode used to encode messages (defaults to BinaryContentMode).
+	ContentMode ContentMode
+	// Header static HTTP headers to be sent along every message (e.g. Authorization).
+	Header http.Header
+}
+
+// Writer is the streams.Writer HTTP implementation.
+//
+// Each message is sent as a CloudEvent through an HTTP POST request to the endpoint configured for its stream.
+// A message is considered written only if the endpoint responded with a 2xx HTTP status code.
+//
+// HTTP has no delayed delivery, so messages with a delivery time (streams.Message DeliverAt) fail with
+// streams.ErrDelayNotSupported.
+type Writer struct {
+	client *http.Client
+	config WriterConfig
+}
+
+var _ streams.Writer = Writer{}
+
+// NewWriter allocates a new Writer ready to be used. If no client was given, http.DefaultClient is used.
+func NewWriter(c *http.Client, cfg WriterConfig) Writer {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return Writer{
+		client: c,
+		config: cfg,
+	}
+}
+
+// Write sends the given message to the endpoint configured for its stream.
+func (w Writer) Write(ctx context.Context, message streams.Message) error {
+	if message.Delay() > 0 {
+		return streams.ErrDelayNotSupported
+	}
+	endpoint, ok := w.config.Endpoints[message.Stream]
+	if !ok {
+		return ErrMissingEndpoint
+	}
+	header, body, err := encodeMessage(w.config.ContentMode, message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range w.config.Header {
+		req.Header[k] = v
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// drain body so the underlying connection might be reused
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", ErrUnexpectedStatusCode, res.StatusCode)
+	}
+	return nil
+}
+
+// WriteBatch sends each message of the given set to the endpoint configured for its stream.
+//
+// Every message is sent even if a previous one failed; returned result contains the failure of each message.
+func (w Writer) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	for i, msg := range messages {
+		res[i].Err = w.Write(ctx, msg)
+	}
+	return res, res.Err()
+}
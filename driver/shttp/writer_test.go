@@ -0,0 +1,134 @@
+package shttp_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/cloudevents"
+	"github.com/neutrinocorp/streams/driver/shttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMessage(id, stream string) streams.Message {
+	return streams.Message{
+		ID:              id,
+		Stream:          stream,
+		Source:          "org.neutrino.test",
+		SpecVersion:     streams.CloudEventsSpecVersion,
+		Type:            "org.neutrino.test." + stream,
+		DataContentType: "application/json",
+		Data:            []byte(`{"foo":"bar"}`),
+	}
+}
+
+func TestWriter_Write(t *testing.T) {
+	mu := sync.Mutex{}
+	var requests []*http.Request
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		if r.URL.Path == "/failing" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	writer := shttp.NewWriter(srv.Client(), shttp.WriterConfig{
+		Endpoints: map[string]string{
+			"foo-stream": srv.URL + "/foo",
+			"bar-stream": srv.URL + "/failing",
+		},
+		Header: http.Header{"Authorization": {"Bearer abc"}},
+	})
+
+	err := writer.Write(context.Background(), newTestMessage("1", "baz-stream"))
+	assert.ErrorIs(t, err, shttp.ErrMissingEndpoint)
+	err = writer.Write(context.Background(), streams.Message{Stream: "foo-stream"})
+	assert.ErrorIs(t, err, cloudevents.ErrInvalidSpecVersion)
+	err = writer.Write(context.Background(), newTestMessage("1", "bar-stream"))
+	assert.ErrorIs(t, err, shttp.ErrUnexpectedStatusCode)
+
+	msg := newTestMessage("2", "foo-stream")
+	require.NoError(t, writer.Write(context.Background(), msg))
+	mu.Lock()
+	defer mu.Unlock()
+	req := requests[len(requests)-1]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "/foo", req.URL.Path)
+	assert.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "2", req.Header.Get("ce-id"))
+	assert.Equal(t, msg.Data, bodies[len(bodies)-1])
+}
+
+func TestWriter_Write_Structured(t *testing.T) {
+	messages := make(chan streams.Message, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, cloudevents.ContentTypeStructuredJSON, r.Header.Get("Content-Type"))
+		msg, err := cloudevents.UnmarshalStructured(body)
+		assert.NoError(t, err)
+		messages <- msg
+	}))
+	defer srv.Close()
+
+	writer := shttp.NewWriter(nil, shttp.WriterConfig{
+		Endpoints:   map[string]string{"foo-stream": srv.URL},
+		ContentMode: shttp.StructuredContentMode,
+	})
+	msg := newTestMessage("1", "foo-stream")
+	require.NoError(t, writer.Write(context.Background(), msg))
+	assert.Equal(t, msg, <-messages)
+}
+
+func TestWriter_WriteBatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	writer := shttp.NewWriter(srv.Client(), shttp.WriterConfig{
+		Endpoints: map[string]string{"foo-stream": srv.URL},
+	})
+	res, err := writer.WriteBatch(context.Background(),
+		newTestMessage("1", "foo-stream"),
+		newTestMessage("2", "bar-stream"),
+		newTestMessage("3", "foo-stream"))
+	assert.Equal(t, uint32(2), res.Succeeded())
+	assert.ErrorIs(t, err, shttp.ErrMissingEndpoint)
+	assert.ErrorIs(t, res.Errors()["2"], shttp.ErrMissingEndpoint)
+}
+
+func TestWriter_Write_Delay(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	writer := shttp.NewWriter(srv.Client(), shttp.WriterConfig{
+		Endpoints: map[string]string{"foo-stream": srv.URL},
+	})
+	delayed := newTestMessage("2", "foo-stream")
+	delayed.DeliverAt = time.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano)
+	assert.ErrorIs(t, writer.Write(context.Background(), delayed), streams.ErrDelayNotSupported)
+	res, err := writer.WriteBatch(context.Background(), newTestMessage("1", "foo-stream"), delayed)
+	assert.ErrorIs(t, err, streams.ErrDelayNotSupported)
+	assert.Equal(t, uint32(1), res.Succeeded())
+	// delayed messages are not sent
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,151 @@
+// Package encryption implements a streams.Marshaler decorator encrypting message data using envelope encryption:
+// every message is encrypted with a fresh AES-256-GCM data key, which gets wrapped (encrypted) by a KeyProvider.
+//
+// The wrapped data key and the ID of the key used to wrap it travel along with the encrypted data, so readers
+// decrypt messages written before a key rotation as long as the KeyProvider still holds the previous key.
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/neutrinocorp/streams"
+)
+
+// HeaderEncryptionKeyID message header holding the ID of the key used to wrap the data key of a message.
+//
+// The header is informational (e.g. for auditing); readers use the key ID embedded in the encrypted data.
+const HeaderEncryptionKeyID = "encryptionkeyid"
+
+// envelopeVersion first byte of every envelope, reserved to evolve the envelope format.
+const envelopeVersion byte = 1
+
+// dataKeySize data key size in bytes (AES-256).
+const dataKeySize = 32
+
+// ErrInvalidEnvelope the given data is not an encrypted envelope.
+var ErrInvalidEnvelope = errors.New("streams: Invalid encryption envelope")
+
+// Marshaler is a streams.Marshaler decorator encrypting the output of the decorated Marshaler.
+//
+// Encrypted data is laid out as follows: version (1 byte), key ID length (1 byte), key ID, wrapped data key length
+// (2 bytes, big endian), wrapped data key, nonce and ciphertext. Every field preceding the nonce is authenticated.
+type Marshaler struct {
+	next     streams.Marshaler
+	provider KeyProvider
+}
+
+var _ streams.HeadersMarshaler = Marshaler{}
+
+// NewMarshaler allocates a new Marshaler encrypting the output of the given streams.Marshaler with data keys
+// wrapped by the given KeyProvider.
+//
+// Data SHOULD be compressed before encryption, so a compressing Marshaler must be decorated by this one and not
+// the other way around.
+func NewMarshaler(m streams.Marshaler, p KeyProvider) Marshaler {
+	return Marshaler{
+		next:     m,
+		provider: p,
+	}
+}
+
+// Marshal transforms a complex data type into an encrypted binary array.
+func (m Marshaler) Marshal(schemaDef string, data interface{}) ([]byte, error) {
+	plaintext, err := m.next.Marshal(schemaDef, data)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	keyID := m.provider.CurrentKeyID()
+	wrappedKey, err := m.provider.WrapKey(keyID, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyID) > math.MaxUint8 || len(wrappedKey) > math.MaxUint16 {
+		return nil, ErrInvalidEnvelope
+	}
+
+	header := make([]byte, 0, 4+len(keyID)+len(wrappedKey))
+	header = append(header, envelopeVersion, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = append(header, byte(len(wrappedKey)>>8), byte(len(wrappedKey)))
+	header = append(header, wrappedKey...)
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(aead, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+// Unmarshal decrypts the given binary array and transforms it into a complex data type.
+//
+// Returns ErrInvalidEnvelope if data is not encrypted and ErrKeyNotFound if the KeyProvider has no longer the key
+// used to wrap the data key.
+func (m Marshaler) Unmarshal(schemaDef string, data []byte, ref interface{}) error {
+	keyID, wrappedKey, sealed, err := parseEnvelope(data)
+	if err != nil {
+		return err
+	}
+	dataKey, err := m.provider.UnwrapKey(keyID, wrappedKey)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	plaintext, err := open(aead, sealed, data[:len(data)-len(sealed)])
+	if err != nil {
+		return err
+	}
+	return m.next.Unmarshal(schemaDef, plaintext, ref)
+}
+
+// ContentType retrieves the encoding/decoding format of the decorated Marshaler.
+func (m Marshaler) ContentType() string {
+	return m.next.ContentType()
+}
+
+// Headers retrieves the headers of the decorated Marshaler along with the HeaderEncryptionKeyID header.
+func (m Marshaler) Headers() map[string]string {
+	headers := map[string]string{}
+	if marshaler, ok := m.next.(streams.HeadersMarshaler); ok {
+		for k, v := range marshaler.Headers() {
+			headers[k] = v
+		}
+	}
+	headers[HeaderEncryptionKeyID] = m.provider.CurrentKeyID()
+	return headers
+}
+
+// parseEnvelope retrieves the key ID, wrapped data key and sealed data (nonce and ciphertext) from the given
+// envelope.
+func parseEnvelope(data []byte) (keyID string, wrappedKey, sealed []byte, err error) {
+	if len(data) < 2 || data[0] != envelopeVersion {
+		return "", nil, nil, ErrInvalidEnvelope
+	}
+	keyIDLen := int(data[1])
+	data = data[2:]
+	if len(data) < keyIDLen+2 {
+		return "", nil, nil, ErrInvalidEnvelope
+	}
+	keyID, data = string(data[:keyIDLen]), data[keyIDLen:]
+	wrappedKeyLen := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	if len(data) < wrappedKeyLen {
+		return "", nil, nil, ErrInvalidEnvelope
+	}
+	return keyID, data[:wrappedKeyLen], data[wrappedKeyLen:], nil
+}
@@ -0,0 +1,100 @@
+package encryption_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/compression"
+	"github.com/neutrinocorp/streams/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fooMessage struct {
+	Foo string `json:"foo"`
+}
+
+func TestMarshaler(t *testing.T) {
+	keys := map[string][]byte{
+		"v1": bytes.Repeat([]byte("1"), 32),
+		"v2": bytes.Repeat([]byte("2"), 32),
+	}
+	before, err := encryption.NewStaticKeyProvider("v1", keys)
+	require.NoError(t, err)
+	m := encryption.NewMarshaler(streams.JSONMarshaler{}, before)
+	assert.Equal(t, streams.MarshalerJSONContentType, m.ContentType())
+	assert.Equal(t, map[string]string{encryption.HeaderEncryptionKeyID: "v1"}, m.Headers())
+
+	payload := fooMessage{Foo: "secret"}
+	data, err := m.Marshal("", payload)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	other, err := m.Marshal("", payload)
+	require.NoError(t, err)
+	assert.NotEqual(t, data, other)
+
+	// messages written before a rotation are still decrypted
+	after, err := encryption.NewStaticKeyProvider("v2", keys)
+	require.NoError(t, err)
+	m = encryption.NewMarshaler(streams.JSONMarshaler{}, after)
+	out := fooMessage{}
+	require.NoError(t, m.Unmarshal("", data, &out))
+	assert.Equal(t, payload, out)
+
+	removed, err := encryption.NewStaticKeyProvider("v2", map[string][]byte{"v2": keys["v2"]})
+	require.NoError(t, err)
+	err = encryption.NewMarshaler(streams.JSONMarshaler{}, removed).Unmarshal("", data, &out)
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+
+	// tampered envelopes are rejected
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 0xff
+	assert.Error(t, m.Unmarshal("", tampered, &out))
+	tampered = append([]byte{}, data...)
+	tampered[2] = '2' // key ID v1 -> v2
+	assert.Error(t, m.Unmarshal("", tampered, &out))
+
+	assert.ErrorIs(t, m.Unmarshal("", []byte(`{"foo":"secret"}`), &out), encryption.ErrInvalidEnvelope)
+	assert.ErrorIs(t, m.Unmarshal("", data[:10], &out), encryption.ErrInvalidEnvelope)
+	assert.ErrorIs(t, m.Unmarshal("", nil, &out), encryption.ErrInvalidEnvelope)
+}
+
+func TestMarshaler_Hub(t *testing.T) {
+	p, err := encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": bytes.Repeat([]byte("1"), 32)})
+	require.NoError(t, err)
+	hub := streams.NewHub(streams.WithMarshaler(encryption.NewMarshaler(
+		compression.NewMarshaler(streams.JSONMarshaler{}, compression.Gzip), p)))
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	var written streams.Message
+	hub.Writer = writerFunc(func(_ context.Context, message streams.Message) error {
+		written = message
+		return nil
+	})
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "secret"}))
+	assert.Equal(t, map[string]string{
+		compression.HeaderContentEncoding: "gzip",
+		encryption.HeaderEncryptionKeyID:  "v1",
+	}, written.Headers)
+
+	out := fooMessage{}
+	require.NoError(t, hub.Marshaler.Unmarshal("", written.Data, &out))
+	assert.Equal(t, fooMessage{Foo: "secret"}, out)
+}
+
+type writerFunc func(context.Context, streams.Message) error
+
+func (f writerFunc) Write(ctx context.Context, message streams.Message) error {
+	return f(ctx, message)
+}
+
+func (f writerFunc) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	for i, msg := range messages {
+		res[i].Err = f(ctx, msg)
+	}
+	return res, res.Err()
+}
@@ -0,0 +1,114 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrKeyNotFound the key-encryption key was not found in the KeyProvider.
+	ErrKeyNotFound = errors.New("streams: Encryption key not found")
+	// ErrInvalidKeySize the key is not a valid AES-128, AES-192 or AES-256 key.
+	ErrInvalidKeySize = errors.New("streams: Invalid encryption key size")
+)
+
+// KeyProvider wraps (encrypts) and unwraps data keys using key-encryption keys (KEK) identified by a key ID
+// (e.g. a local key ring or a remote key management service such as AWS KMS).
+//
+// Keys are rotated by changing the current key ID while keeping previous keys available to unwrap data keys of
+// already written messages.
+type KeyProvider interface {
+	// CurrentKeyID retrieves the ID of the key used to wrap new data keys.
+	CurrentKeyID() string
+	// WrapKey encrypts the given data key using the key with the given ID.
+	WrapKey(keyID string, dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts the given wrapped data key using the key with the given ID.
+	UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider holding a fixed set of AES key-encryption keys in memory, wrapping data keys
+// using AES-GCM.
+type StaticKeyProvider struct {
+	currentKeyID string
+	keys         map[string]cipher.AEAD
+}
+
+var _ KeyProvider = StaticKeyProvider{}
+
+// NewStaticKeyProvider allocates a new StaticKeyProvider with the given keys (16, 24 or 32 bytes long) indexed by
+// their ID. New data keys are wrapped using the key with the given current ID; the rest of the keys are used to
+// unwrap data keys of messages written before a rotation.
+func NewStaticKeyProvider(currentKeyID string, keys map[string][]byte) (StaticKeyProvider, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return StaticKeyProvider{}, ErrKeyNotFound
+	}
+	p := StaticKeyProvider{
+		currentKeyID: currentKeyID,
+		keys:         make(map[string]cipher.AEAD, len(keys)),
+	}
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return StaticKeyProvider{}, err
+		}
+		p.keys[id] = aead
+	}
+	return p, nil
+}
+
+// CurrentKeyID retrieves the ID of the key used to wrap new data keys.
+func (p StaticKeyProvider) CurrentKeyID() string {
+	return p.currentKeyID
+}
+
+// WrapKey encrypts the given data key using the key with the given ID.
+func (p StaticKeyProvider) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return seal(aead, dataKey, []byte(keyID))
+}
+
+// UnwrapKey decrypts the given wrapped data key using the key with the given ID.
+func (p StaticKeyProvider) UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return open(aead, wrappedKey, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the given plaintext using a random nonce, prepending the nonce to the returned ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the given ciphertext produced by seal.
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,40 @@
+package encryption_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/neutrinocorp/streams/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStaticKeyProvider(t *testing.T) {
+	_, err := encryption.NewStaticKeyProvider("v2", map[string][]byte{"v1": bytes.Repeat([]byte("k"), 32)})
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+	_, err = encryption.NewStaticKeyProvider("v1", map[string][]byte{"v1": []byte("short")})
+	assert.ErrorIs(t, err, encryption.ErrInvalidKeySize)
+
+	p, err := encryption.NewStaticKeyProvider("v1", map[string][]byte{
+		"v1": bytes.Repeat([]byte("k"), 16),
+		"v2": bytes.Repeat([]byte("k"), 32),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "v1", p.CurrentKeyID())
+
+	dataKey := []byte("data-key")
+	wrapped, err := p.WrapKey("v1", dataKey)
+	require.NoError(t, err)
+	assert.NotContains(t, string(wrapped), string(dataKey))
+	unwrapped, err := p.UnwrapKey("v1", wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	// wrapped keys are bound to their key ID
+	_, err = p.UnwrapKey("v2", wrapped)
+	assert.Error(t, err)
+	_, err = p.UnwrapKey("v3", wrapped)
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+	_, err = p.WrapKey("v3", dataKey)
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+}
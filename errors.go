@@ -1,6 +1,97 @@
 package streams
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
-// ErrMissingWriterDriver no publisher driver was found.
-var ErrMissingWriterDriver = errors.New("streams: Missing writer driver")
+var (
+	// ErrMissingWriterDriver no publisher driver was found.
+	ErrMissingWriterDriver = errors.New("streams: Missing writer driver")
+	// ErrMissingReaderHandler the stream-reading task has no handler to execute.
+	ErrMissingReaderHandler = errors.New("streams: Missing reader task handler")
+	// ErrHubClosed the Hub is shutting down or has been shut down, so it will not process more messages.
+	ErrHubClosed = errors.New("streams: Hub is closed")
+	// ErrWriterClosed the Writer is shutting down or has been shut down, so it will not accept more messages.
+	ErrWriterClosed = errors.New("streams: Writer is closed")
+	// ErrMissingRoute the RoutingWriter has no route for the stream of a message.
+	ErrMissingRoute = errors.New("streams: Missing writer route for stream")
+	// ErrBatchItemUnknown the Writer did not report the result of a message from a batch.
+	ErrBatchItemUnknown = errors.New("streams: Unknown batch item result")
+	// ErrMessageSettled the message was already acknowledged or rejected.
+	ErrMessageSettled = errors.New("streams: Message already settled")
+	// ErrCircuitOpen the CircuitBreaker is open, so the execution was not performed.
+	ErrCircuitOpen = errors.New("streams: Circuit breaker is open")
+	// ErrDelayNotSupported the message has a delivery time (Message.DeliverAt) but the Writer driver has no delayed
+	// delivery. Use NewSchedulerWriterBehaviour with a zero maximum delay to hand these messages over to a Scheduler.
+	ErrDelayNotSupported = errors.New("streams: Message delay not supported by writer driver")
+)
+
+// PermanentError is an error which MUST NOT be retried (e.g. a malformed message), so the retry ReaderBehaviour stops
+// retrying at once.
+type PermanentError struct {
+	Err error
+}
+
+var _ error = &PermanentError{}
+
+// Permanent wraps the given error into a PermanentError. Returns nil if the given error is nil.
+//
+// Use it from a ReaderHandleFunc to signal the message processing will never succeed (e.g. poison messages).
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether the given error (or any error in its chain) is a PermanentError.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// Error retrieves the text of the wrapped error.
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap retrieves the wrapped error.
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is a set of errors returned by several components from a single operation (e.g. Hub.Shutdown).
+type MultiError []error
+
+var _ error = MultiError{}
+
+// Error retrieves the text of every inner error separated by a semicolon.
+func (m MultiError) Error() string {
+	buff := strings.Builder{}
+	for i, err := range m {
+		if i > 0 {
+			buff.WriteString("; ")
+		}
+		buff.WriteString(err.Error())
+	}
+	return buff.String()
+}
+
+// Is reports whether any inner error matches the given target (using errors.Is).
+func (m MultiError) Is(target error) bool {
+	for _, err := range m {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorOrNil retrieves nil if the set is empty; otherwise, it retrieves the set itself.
+func (m MultiError) ErrorOrNil() error {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
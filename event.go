@@ -15,3 +15,21 @@ type Event interface {
 	// subset of events.
 	GetSubject() string
 }
+
+// Partitioned is a message with an ordering key (aka. partition key), used by streams to populate the PartitionKey
+// field of a Message.
+//
+// Messages sharing a key are delivered in the same order they were written by drivers supporting ordering.
+type Partitioned interface {
+	// GetPartitionKey retrieves the ordering key of the message (e.g. an aggregate ID).
+	GetPartitionKey() string
+}
+
+// Deduplicated is a message with a deduplication identifier, used by streams to populate the DeduplicationID field
+// of a Message.
+//
+// Drivers supporting deduplication discard messages with an already-written deduplication identifier.
+type Deduplicated interface {
+	// GetDeduplicationID retrieves the deduplication identifier of the message (e.g. an idempotency key).
+	GetDeduplicationID() string
+}
@@ -5,12 +5,16 @@ go 1.17
 require (
 	github.com/cenkalti/backoff/v4 v4.1.2
 	github.com/emirpasic/gods v1.18.1
+	github.com/golang/snappy v0.0.4
 	github.com/google/uuid v1.3.0
 	github.com/hamba/avro v1.6.3
 	github.com/hashicorp/golang-lru v0.5.4
 	github.com/json-iterator/go v1.1.12
+	github.com/klauspost/compress v1.15.9
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/modern-go/reflect2 v1.0.2
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/time v0.3.0
 	google.golang.org/protobuf v1.27.1
 )
 
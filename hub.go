@@ -2,6 +2,8 @@ package streams
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/emirpasic/gods/lists/singlylinkedlist"
 )
@@ -22,8 +24,15 @@ type Hub struct {
 	Reader            Reader
 	ReaderBehaviours  []ReaderBehaviour
 	ReaderBaseOptions []ReaderNodeOption
+	// WriterBehaviours middlewares executed prior Writer for every outgoing message.
+	//
+	// Behaviours will be executed in descending order. The chain is built once, when the first message gets
+	// written, so WriterBehaviours MUST NOT be changed afterwards.
+	WriterBehaviours []WriterBehaviour
 
 	readerSupervisor *readerSupervisor
+	writerOnce       sync.Once
+	writerChain      Writer
 }
 
 // NewHub allocates a new Hub
@@ -42,6 +51,7 @@ func NewHub(opts ...HubOption) *Hub {
 		Reader:            baseOpts.driver,
 		ReaderBehaviours:  append(ReaderBaseBehaviours, baseOpts.readerBehaviours...),
 		ReaderBaseOptions: baseOpts.readerBaseOpts,
+		WriterBehaviours:  baseOpts.writerBehaviours,
 	}
 	h.readerSupervisor = newReaderSupervisor(h)
 	return h
@@ -103,56 +113,76 @@ func (h *Hub) Start(ctx context.Context) {
 	h.readerSupervisor.startNodes(ctx)
 }
 
+// Shutdown stops all daemons (e.g. stream-listening jobs) processes gracefully.
+//
+// It stops fetching new messages, waits for in-flight ReaderHandleFunc executions to finish and flushes the Writer
+// (if it implements Shutdowner). The process is bounded by the given context deadline.
+//
+// Returns a MultiError with the errors of every component which failed to shut down.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	errs := h.readerSupervisor.shutdown(ctx)
+	if shutdowner, ok := h.Writer.(Shutdowner); ok {
+		if err := shutdowner.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Close stops all daemons (e.g. stream-listening jobs) processes gracefully, waiting indefinitely for in-flight
+// processes to finish.
+//
+// Use Shutdown to bound the waiting time.
+func (h *Hub) Close() error {
+	return h.Shutdown(context.Background())
+}
+
 // Write inserts a message into a stream assigned to the message in the StreamRegistry in order to propagate the
 // data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
-func (h *Hub) Write(ctx context.Context, message interface{}) error {
+// Uses given context to inject correlation and causation IDs, along with message headers.
+func (h *Hub) Write(ctx context.Context, message interface{}, opts ...WriteOption) error {
 	metadata, err := h.StreamRegistry.Get(message)
 	if err != nil {
 		return err
 	}
-	return h.writeMessage(ctx, metadata, message)
+	return h.writeMessage(ctx, metadata, message, newWriteOptions(opts))
 }
 
 // WriteBatch inserts a set of messages into a stream assigned on the StreamRegistry in order to propagate the
 // data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
+// Uses given context to inject correlation and causation IDs, along with message headers.
 //
-// If an item from the batch fails, other items will fail too
-func (h *Hub) WriteBatch(ctx context.Context, messages ...interface{}) (uint32, error) {
-	var (
-		metadata               StreamMetadata
-		transportMessageBuffer = make([]Message, 0, len(messages))
-		transportMessage       Message
-		err                    error
-	)
-	for _, msg := range messages {
-		metadata, err = h.StreamRegistry.Get(msg)
-		if err != nil {
-			return 0, err
-		}
-		transportMessage, err = h.buildTransportMessage(ctx, metadata, msg)
+// Returns the result of each message in the same order messages were given. Messages failing to be built (e.g. not
+// registered into the StreamRegistry) are marked as failed while the rest of the batch is written.
+//
+// WriteOption(s) are not supported as messages are variadic; set message keys through the Partitioned and Deduplicated
+// interfaces, headers through the context (ContextHeaders) or use WriteByMessageKeyBatch instead.
+func (h *Hub) WriteBatch(ctx context.Context, messages ...interface{}) (BatchResult, error) {
+	res := make(BatchResult, len(messages))
+	for i, msg := range messages {
+		metadata, err := h.StreamRegistry.Get(msg)
 		if err != nil {
-			return 0, err
+			res[i].Err = err
+			continue
 		}
-		transportMessageBuffer = append(transportMessageBuffer, transportMessage)
+		res[i].Message, res[i].Err = h.buildTransportMessage(ctx, metadata, msg, writeOptions{})
 	}
-
-	return h.WriteRawMessageBatch(ctx, transportMessageBuffer...)
+	return h.writeBatchResult(ctx, res)
 }
 
 // WriteByMessageKey inserts a message into a stream using the custom message key from StreamRegistry in order to
 // propagate the data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
-func (h *Hub) WriteByMessageKey(ctx context.Context, messageKey string, message interface{}) error {
+// Uses given context to inject correlation and causation IDs, along with message headers.
+func (h *Hub) WriteByMessageKey(ctx context.Context, messageKey string, message interface{},
+	opts ...WriteOption) error {
 	metadata, err := h.StreamRegistry.GetByString(messageKey)
 	if err != nil {
 		return err
 	}
-	return h.writeMessage(ctx, metadata, message)
+	return h.writeMessage(ctx, metadata, message, newWriteOptions(opts))
 }
 
 // WriteByMessageKeyBatchItems items to be written as batch on the Hub.WriteByMessageKeyBatch() function
@@ -161,33 +191,49 @@ type WriteByMessageKeyBatchItems map[string]interface{}
 // WriteByMessageKeyBatch inserts a set of messages into a stream using the custom message key from StreamRegistry in order to
 // propagate the data to a set of subscribed systems for further processing.
 //
-// Uses given context to inject correlation and causation IDs.
+// Uses given context to inject correlation and causation IDs, along with message headers.
+//
+// Returns the result of each message. Messages failing to be built (e.g. message key not registered into the
+// StreamRegistry) are marked as failed while the rest of the batch is written.
 //
-// If an item from the batch fails, other items will fail too
-func (h *Hub) WriteByMessageKeyBatch(ctx context.Context, items WriteByMessageKeyBatchItems) (uint32, error) {
-	var (
-		metadata               StreamMetadata
-		transportMessageBuffer = make([]Message, 0, len(items))
-		transportMessage       Message
-		err                    error
-	)
+// Given WriteOption(s) are applied to every message of the batch, so per-message values (e.g. WithDeduplicationID)
+// SHOULD be set through the Partitioned and Deduplicated interfaces instead.
+func (h *Hub) WriteByMessageKeyBatch(ctx context.Context, items WriteByMessageKeyBatchItems,
+	opts ...WriteOption) (BatchResult, error) {
+	writeOpts := newWriteOptions(opts)
+	res := make(BatchResult, 0, len(items))
 	for messageKey, msg := range items {
-		metadata, err = h.StreamRegistry.GetByString(messageKey)
-		if err != nil {
-			return 0, err
+		item := BatchItemResult{}
+		var metadata StreamMetadata
+		metadata, item.Err = h.StreamRegistry.GetByString(messageKey)
+		if item.Err == nil {
+			item.Message, item.Err = h.buildTransportMessage(ctx, metadata, msg, writeOpts)
 		}
-		transportMessage, err = h.buildTransportMessage(ctx, metadata, msg)
-		if err != nil {
-			return 0, err
-		}
-		transportMessageBuffer = append(transportMessageBuffer, transportMessage)
+		res = append(res, item)
 	}
+	return h.writeBatchResult(ctx, res)
+}
 
-	return h.WriteRawMessageBatch(ctx, transportMessageBuffer...)
+// writes the messages of the given result which were built successfully, merging the Writer result into it.
+func (h *Hub) writeBatchResult(ctx context.Context, res BatchResult) (BatchResult, error) {
+	messages := make([]Message, 0, len(res))
+	indexes := make([]int, 0, len(res))
+	for i, item := range res {
+		if item.Err == nil {
+			messages = append(messages, item.Message)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(messages) > 0 {
+		writeRes, err := h.WriteRawMessageBatch(ctx, messages...)
+		res.merge(indexes, writeRes, err)
+	}
+	return res, res.Err()
 }
 
 // transforms a primitive message into a CloudEvent message ready for transportation.
-func (h *Hub) buildTransportMessage(ctx context.Context, metadata StreamMetadata, message interface{}) (Message, error) {
+func (h *Hub) buildTransportMessage(ctx context.Context, metadata StreamMetadata, message interface{},
+	opts writeOptions) (Message, error) {
 	schemaDef := ""
 	var err error
 	if h.SchemaRegistry != nil {
@@ -220,18 +266,64 @@ func (h *Hub) buildTransportMessage(ctx context.Context, metadata StreamMetadata
 	})
 	transportMsg.CorrelationID = InjectMessageCorrelationID(ctx, transportMsg.ID)
 	transportMsg.CausationID = InjectMessageCausationID(ctx, transportMsg.CorrelationID)
+	transportMsg.Headers = InjectMessageHeaders(ctx, opts.headers)
+	if marshaler, ok := h.Marshaler.(HeadersMarshaler); ok {
+		for k, v := range marshaler.Headers() {
+			if transportMsg.Headers == nil {
+				transportMsg.Headers = map[string]string{}
+			}
+			transportMsg.Headers[k] = v
+		}
+	}
 
 	event, ok := message.(Event)
 	if ok {
 		transportMsg.Subject = event.GetSubject()
 	}
-
+	transportMsg.PartitionKey, transportMsg.DeduplicationID = newMessageKeys(metadata, message, transportMsg.ID, opts)
+	transportMsg.DeliverAt = newMessageDeliveryTime(opts)
 	return transportMsg, nil
 }
 
+// newMessageDeliveryTime retrieves the delivery time (RFC 3339) of a message, if any.
+func newMessageDeliveryTime(opts writeOptions) string {
+	deliverAt := opts.deliverAt
+	if opts.delay > 0 {
+		deliverAt = time.Now().Add(opts.delay)
+	}
+	if deliverAt.IsZero() {
+		return ""
+	}
+	return deliverAt.UTC().Format(time.RFC3339Nano)
+}
+
+// newMessageKeys retrieves the partition key and deduplication ID of a message. Write options take precedence over
+// message interfaces (Partitioned and Deduplicated), which take precedence over the StreamMetadata.
+func newMessageKeys(metadata StreamMetadata, message interface{}, id string,
+	opts writeOptions) (partitionKey, deduplicationID string) {
+	partitionKey = metadata.PartitionKey
+	if metadata.DeduplicateByID {
+		deduplicationID = id
+	}
+	if partitioned, ok := message.(Partitioned); ok && partitioned.GetPartitionKey() != "" {
+		partitionKey = partitioned.GetPartitionKey()
+	}
+	if deduplicated, ok := message.(Deduplicated); ok && deduplicated.GetDeduplicationID() != "" {
+		deduplicationID = deduplicated.GetDeduplicationID()
+	}
+	if opts.partitionKey != "" {
+		partitionKey = opts.partitionKey
+	}
+	if opts.deduplicationID != "" {
+		deduplicationID = opts.deduplicationID
+	}
+	return
+}
+
 // pushes a single message into a stream using cloud events marshaling
-func (h *Hub) writeMessage(ctx context.Context, metadata StreamMetadata, message interface{}) error {
-	transportMsg, err := h.buildTransportMessage(ctx, metadata, message)
+func (h *Hub) writeMessage(ctx context.Context, metadata StreamMetadata, message interface{},
+	opts writeOptions) error {
+	transportMsg, err := h.buildTransportMessage(ctx, metadata, message, opts)
 	if err != nil {
 		return err
 	}
@@ -246,7 +338,7 @@ func (h *Hub) WriteRawMessage(ctx context.Context, message Message) error {
 	if h.Writer == nil {
 		return ErrMissingWriterDriver
 	}
-	return h.Writer.Write(ctx, message)
+	return h.writer().Write(ctx, message)
 }
 
 // WriteRawMessageBatch inserts a set of raw transport message into a stream in order to propagate the data to a set
@@ -255,10 +347,40 @@ func (h *Hub) WriteRawMessage(ctx context.Context, message Message) error {
 // Uses given context to inject correlation and causation IDs.
 //
 // The whole batch will be passed to the underlying Writer driver implementation as every driver has its own way to
-// deal with batches
-func (h *Hub) WriteRawMessageBatch(ctx context.Context, messages ...Message) (uint32, error) {
+// deal with batches. Returns the result of each message in the same order messages were given.
+func (h *Hub) WriteRawMessageBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
 	if h.Writer == nil {
-		return 0, ErrMissingWriterDriver
+		res := NewBatchResult(messages)
+		res.FailAll(ErrMissingWriterDriver)
+		return res, ErrMissingWriterDriver
 	}
-	return h.Writer.WriteBatch(ctx, messages...)
+	return h.writer().WriteBatch(ctx, messages...)
+}
+
+// writer retrieves the Hub Writer wrapped with WriterBehaviours, building the chain on its first call.
+func (h *Hub) writer() Writer {
+	h.writerOnce.Do(func() {
+		var w Writer = hubWriter{hub: h}
+		for _, b := range h.WriterBehaviours {
+			w = b(h, w)
+		}
+		h.writerChain = w
+	})
+	return h.writerChain
+}
+
+// hubWriter forwards writes to the current Hub Writer, so it might be replaced after the WriterBehaviours chain
+// was built.
+type hubWriter struct {
+	hub *Hub
+}
+
+var _ Writer = hubWriter{}
+
+func (w hubWriter) Write(ctx context.Context, message Message) error {
+	return w.hub.Writer.Write(ctx, message)
+}
+
+func (w hubWriter) WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
+	return w.hub.Writer.WriteBatch(ctx, messages...)
 }
@@ -9,6 +9,7 @@ type hubOptions struct {
 	driver           Reader
 	readerBehaviours []ReaderBehaviour
 	readerBaseOpts   []ReaderNodeOption
+	writerBehaviours []WriterBehaviour
 }
 
 // HubOption enables configuration of a Hub instance.
@@ -122,3 +123,17 @@ func (o readerBaseOptions) apply(opts *hubOptions) {
 func WithReaderBaseOptions(opts ...ReaderNodeOption) HubOption {
 	return readerBaseOptions{BaseOpts: opts}
 }
+
+type writerBehavioursOption struct {
+	Behaviours []WriterBehaviour
+}
+
+func (o writerBehavioursOption) apply(opts *hubOptions) {
+	opts.writerBehaviours = o.Behaviours
+}
+
+// WithWriterBehaviours sets a list of WriterBehaviour of a Hub instance ready to be executed for every outgoing
+// message.
+func WithWriterBehaviours(b ...WriterBehaviour) HubOption {
+	return writerBehavioursOption{Behaviours: b}
+}
@@ -75,6 +75,17 @@ func TestWithReaderBehaviours(t *testing.T) {
 	assert.Equal(t, totalDefaultBehaviours+1, len(hub.ReaderBehaviours))
 }
 
+func TestWithWriterBehaviours(t *testing.T) {
+	hub := streams.NewHub()
+	assert.Empty(t, hub.WriterBehaviours)
+
+	hub = streams.NewHub(
+		streams.WithWriterBehaviours(func(_ *streams.Hub, next streams.Writer) streams.Writer {
+			return next
+		}))
+	assert.Len(t, hub.WriterBehaviours, 1)
+}
+
 func TestWithReaderBaseOptions(t *testing.T) {
 	hub := streams.NewHub()
 	assert.NotNil(t, hub.ReaderBaseOptions)
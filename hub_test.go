@@ -64,13 +64,35 @@ func TestHub_WriteBatch(t *testing.T) {
 	_, err = hub.WriteBatch(ctx, fooMessage{
 		Foo: "foo",
 	})
-	assert.EqualValues(t, errors.New("generic id factory error"), err)
+	assert.EqualError(t, err, "generic id factory error")
 
 	hub.IDFactory = streams.RandInt64Factory
-	_, err = hub.WriteBatch(ctx, fooMessage{
+	res, err := hub.WriteBatch(ctx, fooMessage{
 		Foo: "foo",
 	})
 	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), res.Succeeded())
+
+	// only failing items are reported, the rest of the batch is written
+	var written []streams.Message
+	hub.Writer = writerNoopHook{
+		onWriteBatch: func(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+			written = append(written, messages...)
+			res := streams.NewBatchResult(messages)
+			res[1].Err = errors.New("generic writer error")
+			return res, res.Err()
+		},
+	}
+	res, err = hub.WriteBatch(ctx, fooMessage{Foo: "1"}, fooEvent{}, fooMessage{Foo: "2"}, fooMessage{Foo: "3"})
+	assert.ErrorIs(t, err, streams.ErrMissingStream)
+	require.Len(t, res, 4)
+	assert.Len(t, written, 3)
+	assert.Equal(t, uint32(2), res.Succeeded())
+	assert.ErrorIs(t, res[1].Err, streams.ErrMissingStream)
+	assert.EqualError(t, res[2].Err, "generic writer error")
+	assert.Equal(t, written[1], res[2].Message)
+	assert.Equal(t, []streams.Message{written[1]}, res.Failed()[1:].FailedMessages())
+	assert.Len(t, res.Errors(), 2)
 }
 
 func TestHub_Write_Func(t *testing.T) {
@@ -214,19 +236,20 @@ func TestHub_WriteRawMessageBatch(t *testing.T) {
 
 	out, err := hub.WriteRawMessageBatch(ctx, messageBuffer...)
 	assert.ErrorIs(t, err, streams.ErrMissingWriterDriver)
-	assert.Equal(t, uint32(0), out)
+	assert.Equal(t, uint32(0), out.Succeeded())
+	assert.Len(t, out.Failed(), len(messageBuffer))
 
 	totalMessagesPushed := 0
 	hub.Writer = writerNoopHook{
-		onWriteBatch: func(_ context.Context, messages ...streams.Message) (uint32, error) {
+		onWriteBatch: func(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
 			totalMessagesPushed = len(messages)
-			return uint32(totalMessagesPushed), nil
+			return streams.NewBatchResult(messages), nil
 		},
 	}
 	out, err = hub.WriteRawMessageBatch(ctx, messageBuffer...)
 	assert.NoError(t, err)
 	assert.Equal(t, len(messageBuffer), totalMessagesPushed)
-	assert.Equal(t, uint32(len(messageBuffer)), out)
+	assert.Equal(t, uint32(len(messageBuffer)), out.Succeeded())
 
 	// testing noopWriter from streams package to increase test coverage
 	hub.Writer = streams.NoopWriter
@@ -296,7 +319,7 @@ func TestHub_WriteByMessageKeyBatch(t *testing.T) {
 			Foo: "custom",
 		},
 	})
-	assert.EqualValues(t, errors.New("generic id factory error"), err)
+	assert.EqualError(t, err, "generic id factory error")
 
 	hub.IDFactory = streams.RandInt64Factory
 	_, err = hub.WriteByMessageKeyBatch(ctx, map[string]interface{}{
@@ -305,6 +328,17 @@ func TestHub_WriteByMessageKeyBatch(t *testing.T) {
 		},
 	})
 	assert.NoError(t, err)
+
+	// write options are applied to every message
+	res, err := hub.WriteByMessageKeyBatch(ctx, map[string]interface{}{
+		"foo_custom": fooMessage{
+			Foo: "custom",
+		},
+	}, streams.WithHeader("tenantid", "foo"), streams.WithPartitionKey("foo-key"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "foo", res[0].Message.Headers["tenantid"])
+	assert.Equal(t, "foo-key", res[0].Message.PartitionKey)
 }
 
 func TestHub_Read(t *testing.T) {
@@ -502,3 +536,187 @@ func BenchmarkHub_GetStreamReaderNodes(b *testing.B) {
 		_ = hub.GetStreamReaderNodes("foo")
 	}
 }
+
+func TestHub_Shutdown(t *testing.T) {
+	handlerStarted := make(chan struct{})
+	releaseHandler := make(chan struct{})
+	readerShutdowns := 0
+	reader := &readerShutdownHook{
+		onExecuteTask: func(ctx context.Context, task streams.ReaderTask) error {
+			go func() {
+				_ = task.HandlerFunc(ctx, streams.Message{Stream: task.Stream})
+			}()
+			return nil
+		},
+		onShutdown: func(_ context.Context) error {
+			readerShutdowns++
+			return nil
+		},
+	}
+	writerErr := errors.New("generic flush error")
+	hub := streams.NewHub(
+		streams.WithReader(reader),
+		streams.WithWriter(writerShutdownHook{
+			onShutdown: func(_ context.Context) error {
+				return writerErr
+			},
+		}),
+	)
+	hub.ReaderBehaviours = nil
+	handlerCtxErr := make(chan error, 1)
+	hub.ReadByStreamKey("foo-stream", streams.WithHandlerFunc(func(ctx context.Context, _ streams.Message) error {
+		close(handlerStarted)
+		<-releaseHandler
+		handlerCtxErr <- ctx.Err()
+		return nil
+	}))
+	hub.ReadByStreamKey("bar-stream", streams.WithHandlerFunc(func(_ context.Context, _ streams.Message) error {
+		return nil
+	}), streams.WithDriver(reader))
+	hub.Start(context.Background())
+	<-handlerStarted
+
+	// in-flight handler blocks shutdown until deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	err := hub.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, writerErr)
+	// the same reader is shut down once
+	assert.Equal(t, 1, readerShutdowns)
+
+	close(releaseHandler)
+	// stream-reading jobs context gets canceled once shutdown deadline is exceeded
+	assert.ErrorIs(t, <-handlerCtxErr, context.Canceled)
+
+	err = hub.Close()
+	assert.ErrorIs(t, err, writerErr)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHub_Write_Headers(t *testing.T) {
+	hub := streams.NewHub()
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	noopWriter := &writerNoopHook{}
+	hub.Writer = noopWriter
+
+	noopWriter.onWrite = func(_ context.Context, message streams.Message) error {
+		assert.Nil(t, message.Headers)
+		return nil
+	}
+	err := hub.Write(context.Background(), fooMessage{Foo: "foo"})
+	assert.NoError(t, err)
+
+	ctx := streams.ContextWithHeaders(context.Background(), map[string]string{
+		"tenantid":    "foo",
+		"traceparent": "00-abc-def-01",
+	})
+	noopWriter.onWrite = func(_ context.Context, message streams.Message) error {
+		assert.Equal(t, map[string]string{
+			"tenantid":    "bar",
+			"traceparent": "00-abc-def-01",
+			"region":      "baz",
+		}, message.Headers)
+		return nil
+	}
+	err = hub.Write(ctx, fooMessage{Foo: "foo"}, streams.WithHeaders(map[string]string{
+		"tenantid": "bar",
+	}), streams.WithHeader("region", "baz"))
+	assert.NoError(t, err)
+
+	hub.RegisterStreamByString("foo_custom", streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	noopWriter.onWrite = func(_ context.Context, message streams.Message) error {
+		assert.Equal(t, map[string]string{
+			"tenantid":    "foo",
+			"traceparent": "00-abc-def-01",
+		}, message.Headers)
+		return nil
+	}
+	err = hub.WriteByMessageKey(ctx, "foo_custom", fooMessage{Foo: "foo"})
+	assert.NoError(t, err)
+}
+
+type fooOrderedEvent struct {
+	Foo string `json:"foo"`
+}
+
+var (
+	_ streams.Partitioned  = fooOrderedEvent{}
+	_ streams.Deduplicated = fooOrderedEvent{}
+)
+
+func (f fooOrderedEvent) GetPartitionKey() string {
+	return f.Foo
+}
+
+func (f fooOrderedEvent) GetDeduplicationID() string {
+	return "dedup-" + f.Foo
+}
+
+func TestHub_Write_MessageKeys(t *testing.T) {
+	hub := streams.NewHub()
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream:          "foo-stream",
+		PartitionKey:    "foo-key",
+		DeduplicateByID: true,
+	})
+	hub.RegisterStream(fooOrderedEvent{}, streams.StreamMetadata{
+		Stream:          "foo-stream",
+		PartitionKey:    "foo-key",
+		DeduplicateByID: true,
+	})
+	var written []streams.Message
+	hub.Writer = writerNoopHook{
+		onWrite: func(_ context.Context, message streams.Message) error {
+			written = append(written, message)
+			return nil
+		},
+	}
+
+	// stream metadata defaults
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "foo"}))
+	// message interfaces override stream metadata
+	require.NoError(t, hub.Write(context.Background(), fooOrderedEvent{Foo: "bar"}))
+	// write options override everything else
+	require.NoError(t, hub.Write(context.Background(), fooOrderedEvent{Foo: "bar"},
+		streams.WithPartitionKey("baz-key"), streams.WithDeduplicationID("baz")))
+
+	require.Len(t, written, 3)
+	assert.Equal(t, "foo-key", written[0].PartitionKey)
+	assert.Equal(t, written[0].ID, written[0].DeduplicationID)
+	assert.Equal(t, "bar", written[1].PartitionKey)
+	assert.Equal(t, "dedup-bar", written[1].DeduplicationID)
+	assert.Equal(t, "baz-key", written[2].PartitionKey)
+	assert.Equal(t, "baz", written[2].DeduplicationID)
+}
+
+func TestHub_Write_DeliverAt(t *testing.T) {
+	hub := streams.NewHub()
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	var written []streams.Message
+	hub.Writer = writerNoopHook{
+		onWrite: func(_ context.Context, message streams.Message) error {
+			written = append(written, message)
+			return nil
+		},
+	}
+
+	deliverAt := time.Date(2030, 1, 2, 3, 4, 5, 6, time.FixedZone("UTC-6", -6*60*60))
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "foo"}))
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "foo"}, streams.WithDeliverAt(deliverAt)))
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "foo"}, streams.WithDeliverAt(deliverAt),
+		streams.WithDelay(time.Minute)))
+
+	require.Len(t, written, 3)
+	assert.Empty(t, written[0].DeliverAt)
+	assert.Equal(t, "2030-01-02T09:04:05.000000006Z", written[1].DeliverAt)
+	// last option wins
+	delay := written[2].Delay()
+	assert.True(t, delay > time.Second*58 && delay <= time.Minute, delay)
+}
@@ -0,0 +1,133 @@
+// Package inbox implements the idempotent consumer (inbox) pattern for streams: IDs of messages processed by each
+// reader group are recorded into a DedupeStore, so redelivered messages are skipped instead of processed again.
+//
+// Useful for at-least-once drivers (e.g. Amazon SQS), which might deliver a message more than once.
+package inbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+)
+
+// NewReaderBehaviour creates a streams.ReaderBehaviour skipping messages already processed by the ReaderNode's group,
+// according to the given DedupeStore. A message is recorded as processed only after its handler succeeded or, in
+// manual acknowledgement mode, once the handler acknowledged it. Messages rejected by the handler (streams.Acknowledger
+// Nack or Term) or written into the dead-letter stream (which terminates them) are not recorded, so their redeliveries
+// get processed. Handlers of drivers without explicit acknowledgement get an Acknowledger too, only tracking the
+// settlement of the message.
+//
+// Skipped messages are acknowledged. Messages without ID are always processed. Note that concurrent deliveries of the
+// same message might be processed more than once, as they get recorded once their handler finished.
+//
+// Failing to record a message returns the error, so the message gets redelivered and skipped afterwards (if recorded
+// in the meantime) or processed again.
+func NewReaderBehaviour(store DedupeStore) streams.ReaderBehaviour {
+	return func(node *streams.ReaderNode, _ *streams.Hub, next streams.ReaderHandleFunc) streams.ReaderHandleFunc {
+		return func(ctx context.Context, message streams.Message) error {
+			if message.ID == "" {
+				return next(ctx, message)
+			}
+			processed, err := store.Contains(ctx, node.Group, message.ID)
+			if err != nil {
+				return err
+			} else if processed {
+				// handlers in manual acknowledgement mode will never see this message
+				if ack, ok := streams.AcknowledgerFromContext(ctx); ok {
+					return ack.Ack(ctx)
+				}
+				return nil
+			}
+
+			driverAck, ok := streams.AcknowledgerFromContext(ctx)
+			if !ok {
+				driverAck = noopAcknowledger{}
+			}
+			ack := &acknowledger{
+				next:      driverAck,
+				store:     store,
+				group:     node.Group,
+				messageID: message.ID,
+				manual:    node.ManualAck,
+			}
+			message.Acknowledger = ack
+			if err = next(streams.ContextWithAcknowledger(ctx, ack), message); err != nil {
+				return err
+			} else if !ack.recordOnSuccess() {
+				return nil
+			}
+			return store.Save(ctx, node.Group, message.ID)
+		}
+	}
+}
+
+// acknowledger is a streams.Acknowledger decorator keeping track of the settlement of a message, so it is recorded
+// as processed only if acknowledged (manual acknowledgement mode) or not rejected by the handler.
+type acknowledger struct {
+	next      streams.Acknowledger
+	store     DedupeStore
+	group     string
+	messageID string
+	manual    bool
+
+	mu       sync.Mutex
+	rejected bool
+}
+
+var _ streams.Acknowledger = &acknowledger{}
+
+// Ack acknowledges the message and records it as processed in manual acknowledgement mode.
+func (a *acknowledger) Ack(ctx context.Context) error {
+	if err := a.next.Ack(ctx); err != nil {
+		return err
+	} else if !a.manual {
+		return nil
+	}
+	return a.store.Save(ctx, a.group, a.messageID)
+}
+
+// Nack rejects the message, so it is not recorded as processed (even if the rejection failed, as the message will be
+// redelivered anyway).
+func (a *acknowledger) Nack(ctx context.Context, requeueAfter time.Duration) error {
+	a.reject()
+	return a.next.Nack(ctx, requeueAfter)
+}
+
+// Term rejects the message, so it is not recorded as processed.
+func (a *acknowledger) Term(ctx context.Context) error {
+	a.reject()
+	return a.next.Term(ctx)
+}
+
+func (a *acknowledger) reject() {
+	a.mu.Lock()
+	a.rejected = true
+	a.mu.Unlock()
+}
+
+// recordOnSuccess reports whether the message must be recorded once its handler succeeded: in automatic
+// acknowledgement mode and only if the handler did not reject the message.
+func (a *acknowledger) recordOnSuccess() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return !a.manual && !a.rejected
+}
+
+// noopAcknowledger is the streams.Acknowledger of messages read by drivers without explicit acknowledgement.
+type noopAcknowledger struct{}
+
+var _ streams.Acknowledger = noopAcknowledger{}
+
+func (noopAcknowledger) Ack(_ context.Context) error {
+	return nil
+}
+
+func (noopAcknowledger) Nack(_ context.Context, _ time.Duration) error {
+	return nil
+}
+
+func (noopAcknowledger) Term(_ context.Context) error {
+	return nil
+}
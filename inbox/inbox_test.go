@@ -0,0 +1,248 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/driver/shmemory"
+	"github.com/neutrinocorp/streams/inbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore is a DedupeStore failing every operation.
+type failingStore struct {
+	err error
+}
+
+func (s failingStore) Contains(_ context.Context, _, _ string) (bool, error) {
+	return false, s.err
+}
+
+func (s failingStore) Save(_ context.Context, _, _ string) error {
+	return s.err
+}
+
+// acknowledgerRecorder is a streams.Acknowledger recording the name of every call.
+type acknowledgerRecorder struct {
+	calls []string
+}
+
+func (a *acknowledgerRecorder) Ack(_ context.Context) error {
+	a.calls = append(a.calls, "ack")
+	return nil
+}
+
+func (a *acknowledgerRecorder) Nack(_ context.Context, _ time.Duration) error {
+	a.calls = append(a.calls, "nack")
+	return nil
+}
+
+func (a *acknowledgerRecorder) Term(_ context.Context) error {
+	a.calls = append(a.calls, "term")
+	return nil
+}
+
+func TestNewReaderBehaviour(t *testing.T) {
+	store, err := inbox.NewMemoryStore(8, 0)
+	require.NoError(t, err)
+	var received []string
+	var errHandler error
+	handler := inbox.NewReaderBehaviour(store)(&streams.ReaderNode{Group: "foo-group"}, nil,
+		func(_ context.Context, message streams.Message) error {
+			received = append(received, message.ID)
+			return errHandler
+		})
+
+	ctx := context.Background()
+	errHandler = errors.New("generic error")
+	assert.EqualError(t, handler(ctx, streams.Message{ID: "1"}), "generic error")
+	// failed messages are not recorded
+	errHandler = nil
+	require.NoError(t, handler(ctx, streams.Message{ID: "1"}))
+	require.NoError(t, handler(ctx, streams.Message{ID: "1"}))
+	require.NoError(t, handler(ctx, streams.Message{ID: "2"}))
+	require.NoError(t, handler(ctx, streams.Message{}))
+	require.NoError(t, handler(ctx, streams.Message{}))
+	assert.Equal(t, []string{"1", "1", "2", "", ""}, received)
+
+	// skipped messages are acknowledged
+	ack := &acknowledgerRecorder{}
+	require.NoError(t, handler(streams.ContextWithAcknowledger(ctx, ack), streams.Message{ID: "2"}))
+	assert.Equal(t, []string{"ack"}, ack.calls)
+	assert.Len(t, received, 5)
+
+	handler = inbox.NewReaderBehaviour(failingStore{err: errors.New("generic store error")})(
+		&streams.ReaderNode{}, nil, func(_ context.Context, _ streams.Message) error {
+			t.Fatal("unexpected handler execution")
+			return nil
+		})
+	assert.EqualError(t, handler(ctx, streams.Message{ID: "1"}), "generic store error")
+}
+
+func TestNewReaderBehaviour_Acknowledger(t *testing.T) {
+	store, err := inbox.NewMemoryStore(8, 0)
+	require.NoError(t, err)
+	var received []string
+	reject := true
+	node := &streams.ReaderNode{Group: "foo-group"}
+	newHandler := func() streams.ReaderHandleFunc {
+		return inbox.NewReaderBehaviour(store)(node, nil,
+			func(ctx context.Context, message streams.Message) error {
+				received = append(received, message.ID)
+				if reject {
+					return message.Acknowledger.Nack(ctx, 0)
+				} else if node.ManualAck {
+					ack, _ := streams.AcknowledgerFromContext(ctx)
+					return ack.Ack(ctx)
+				}
+				return nil
+			})
+	}
+	deliver := func(handler streams.ReaderHandleFunc, id string) []string {
+		ack := &acknowledgerRecorder{}
+		require.NoError(t, handler(streams.ContextWithAcknowledger(context.Background(), ack),
+			streams.Message{ID: id, Acknowledger: ack}))
+		return ack.calls
+	}
+
+	// rejected messages are not recorded, so their redeliveries are processed
+	handler := newHandler()
+	assert.Equal(t, []string{"nack"}, deliver(handler, "1"))
+	reject = false
+	assert.Empty(t, deliver(handler, "1"))
+	assert.Equal(t, []string{"ack"}, deliver(handler, "1"))
+	assert.Equal(t, []string{"1", "1"}, received)
+
+	// messages are recorded once acknowledged in manual acknowledgement mode
+	node.ManualAck = true
+	handler = newHandler()
+	reject = true
+	assert.Equal(t, []string{"nack"}, deliver(handler, "2"))
+	reject = false
+	assert.Equal(t, []string{"ack"}, deliver(handler, "2"))
+	assert.Equal(t, []string{"ack"}, deliver(handler, "2"))
+	assert.Equal(t, []string{"1", "1", "2", "2"}, received)
+}
+
+func TestNewReaderBehaviour_NoAcknowledger(t *testing.T) {
+	store, err := inbox.NewMemoryStore(8, 0)
+	require.NoError(t, err)
+	terminate := true
+	handler := inbox.NewReaderBehaviour(store)(&streams.ReaderNode{Group: "foo-group"}, nil,
+		func(ctx context.Context, _ streams.Message) error {
+			if !terminate {
+				return nil
+			}
+			// e.g. dead-lettered messages
+			ack, ok := streams.AcknowledgerFromContext(ctx)
+			require.True(t, ok)
+			return ack.Term(ctx)
+		})
+
+	// settlement is tracked even if the driver has no explicit acknowledgement
+	require.NoError(t, handler(context.Background(), streams.Message{ID: "1"}))
+	ok, err := store.Contains(context.Background(), "foo-group", "1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	terminate = false
+	require.NoError(t, handler(context.Background(), streams.Message{ID: "1"}))
+	ok, err = store.Contains(context.Background(), "foo-group", "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNewReaderBehaviour_DeadLetter(t *testing.T) {
+	store, err := inbox.NewMemoryStore(8, 0)
+	require.NoError(t, err)
+	bus := shmemory.NewBus(0)
+	hub := streams.NewHub(
+		streams.WithWriter(shmemory.NewWriter(bus)),
+		streams.WithReader(shmemory.NewReader(bus)),
+		streams.WithReaderBehaviours(inbox.NewReaderBehaviour(store)))
+	for _, stream := range []string{"foo-stream", "foo-dlq"} {
+		hub.RegisterStreamByString(stream, streams.StreamMetadata{Stream: stream})
+	}
+
+	received := make(chan string, 4)
+	var deliveries int32
+	hub.ReadByStreamKey("foo-stream", streams.WithGroup("foo-group"), streams.WithDeadLetterStream("foo-dlq"),
+		streams.WithHandlerFunc(func(_ context.Context, message streams.Message) error {
+			received <- message.ID
+			if atomic.AddInt32(&deliveries, 1) == 1 {
+				return streams.Permanent(errors.New("generic error"))
+			}
+			return nil
+		}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub.Start(ctx)
+
+	message := streams.Message{ID: "1", Stream: "foo-stream"}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, hub.WriteRawMessage(context.Background(), message))
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+		// dead-lettered messages are not recorded, so their redeliveries are processed
+		time.Sleep(time.Millisecond * 20)
+	}
+	ok, err := store.Contains(context.Background(), "foo-group", "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNewReaderBehaviour_Hub(t *testing.T) {
+	store, err := inbox.NewMemoryStore(8, time.Minute)
+	require.NoError(t, err)
+	bus := shmemory.NewBus(0)
+	hub := streams.NewHub(
+		streams.WithWriter(shmemory.NewWriter(bus)),
+		streams.WithReader(shmemory.NewReader(bus)),
+		streams.WithReaderBehaviours(inbox.NewReaderBehaviour(store)))
+	hub.RegisterStreamByString("foo-stream", streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+
+	received := make(chan string, 4)
+	for _, group := range []string{"foo-group", "bar-group"} {
+		group := group
+		hub.ReadByStreamKey("foo-stream", streams.WithGroup(group),
+			streams.WithHandlerFunc(func(_ context.Context, message streams.Message) error {
+				received <- group + "/" + message.ID
+				return nil
+			}))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub.Start(ctx)
+
+	message := streams.Message{ID: "1", Stream: "foo-stream"}
+	require.NoError(t, hub.WriteRawMessage(context.Background(), message))
+	var got []string
+	for i := 0; i < 2; i++ {
+		select {
+		case id := <-received:
+			got = append(got, id)
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	}
+	assert.ElementsMatch(t, []string{"foo-group/1", "bar-group/1"}, got)
+
+	// redeliveries are skipped by every group
+	time.Sleep(time.Millisecond * 20)
+	require.NoError(t, hub.WriteRawMessage(context.Background(), message))
+	select {
+	case id := <-received:
+		t.Fatalf("unexpected redelivery %s", id)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
@@ -0,0 +1,53 @@
+package inbox
+
+import (
+	"context"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// MemoryStore is the in-memory implementation of DedupeStore, keeping the most recently processed message IDs for
+// a limited duration.
+//
+// IDs are lost on restart, so redeliveries of messages processed by a previous process are not skipped.
+type MemoryStore struct {
+	cache *lru.Cache
+	ttl   time.Duration
+}
+
+var _ DedupeStore = MemoryStore{}
+
+type memoryStoreKey struct {
+	group     string
+	messageID string
+}
+
+// NewMemoryStore allocates a new MemoryStore holding up to size message IDs, evicting the least recently used ones
+// first. Message IDs expire after the given duration, 0 to keep them until evicted.
+func NewMemoryStore(size int, ttl time.Duration) (MemoryStore, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return MemoryStore{}, err
+	}
+	return MemoryStore{cache: cache, ttl: ttl}, nil
+}
+
+// Contains reports whether the given message ID is in the cache and has not expired.
+func (s MemoryStore) Contains(_ context.Context, group, messageID string) (bool, error) {
+	key := memoryStoreKey{group: group, messageID: messageID}
+	expiresAt, ok := s.cache.Get(key)
+	if !ok {
+		return false, nil
+	} else if s.ttl > 0 && time.Now().After(expiresAt.(time.Time)) {
+		s.cache.Remove(key)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Save adds the given message ID into the cache.
+func (s MemoryStore) Save(_ context.Context, group, messageID string) error {
+	s.cache.Add(memoryStoreKey{group: group, messageID: messageID}, time.Now().Add(s.ttl))
+	return nil
+}
@@ -0,0 +1,49 @@
+package inbox_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams/inbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	_, err := inbox.NewMemoryStore(0, 0)
+	assert.Error(t, err)
+
+	store, err := inbox.NewMemoryStore(2, 0)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Save(ctx, "foo-group", "1"))
+	require.NoError(t, store.Save(ctx, "bar-group", "2"))
+
+	ok, err := store.Contains(ctx, "foo-group", "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	// message IDs are scoped by group
+	ok, _ = store.Contains(ctx, "bar-group", "1")
+	assert.False(t, ok)
+
+	// least recently used IDs are evicted
+	require.NoError(t, store.Save(ctx, "foo-group", "3"))
+	ok, _ = store.Contains(ctx, "bar-group", "2")
+	assert.False(t, ok)
+	ok, _ = store.Contains(ctx, "foo-group", "1")
+	assert.True(t, ok)
+}
+
+func TestMemoryStore_TTL(t *testing.T) {
+	store, err := inbox.NewMemoryStore(8, time.Millisecond*10)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Save(ctx, "foo-group", "1"))
+	ok, _ := store.Contains(ctx, "foo-group", "1")
+	assert.True(t, ok)
+
+	time.Sleep(time.Millisecond * 20)
+	ok, _ = store.Contains(ctx, "foo-group", "1")
+	assert.False(t, ok)
+}
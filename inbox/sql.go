@@ -0,0 +1,111 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+// DefaultTable default name of the inbox table.
+const DefaultTable = "streams_inbox"
+
+// Placeholder query parameter placeholder format of a database.
+type Placeholder uint8
+
+const (
+	// QuestionPlaceholder uses ? placeholders (e.g. MySQL, SQLite).
+	QuestionPlaceholder Placeholder = iota
+	// DollarPlaceholder uses $N placeholders (e.g. PostgreSQL).
+	DollarPlaceholder
+)
+
+// format retrieves the placeholder of the n-th (starting from 1) query parameter.
+func (p Placeholder) format(n int) string {
+	if p == DollarPlaceholder {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// Config SQLStore configuration.
+type Config struct {
+	// Table name of the inbox table.
+	Table       string
+	Placeholder Placeholder
+}
+
+// DefaultConfig default inbox configuration.
+var DefaultConfig = Config{
+	Table:       DefaultTable,
+	Placeholder: QuestionPlaceholder,
+}
+
+// SQLStore is the durable database implementation of DedupeStore (e.g. SQLite, PostgreSQL, MySQL).
+//
+// The inbox table is expected to have the following columns (types might vary depending on the database):
+//
+//	CREATE TABLE streams_inbox (
+//		group_name   VARCHAR(255) NOT NULL,
+//		message_id   VARCHAR(255) NOT NULL,
+//		processed_at BIGINT NOT NULL,      -- unix time in nanoseconds
+//		PRIMARY KEY (group_name, message_id)
+//	);
+//	CREATE INDEX streams_inbox_processed ON streams_inbox (processed_at);
+//
+// Rows are never deleted by the store itself; use Purge periodically to bound the table size.
+type SQLStore struct {
+	db          *sql.DB
+	selectQuery string
+	insertQuery string
+	deleteQuery string
+}
+
+var _ DedupeStore = SQLStore{}
+
+// NewSQLStore allocates a new SQLStore using the given database. Uses DefaultTable if the configuration has no table.
+func NewSQLStore(db *sql.DB, cfg Config) SQLStore {
+	if cfg.Table == "" {
+		cfg.Table = DefaultConfig.Table
+	}
+	return SQLStore{
+		db: db,
+		selectQuery: "SELECT COUNT(*) FROM " + cfg.Table + " WHERE group_name = " + cfg.Placeholder.format(1) +
+			" AND message_id = " + cfg.Placeholder.format(2),
+		insertQuery: "INSERT INTO " + cfg.Table + " (group_name, message_id, processed_at) VALUES (" +
+			cfg.Placeholder.format(1) + ", " + cfg.Placeholder.format(2) + ", " + cfg.Placeholder.format(3) + ")",
+		deleteQuery: "DELETE FROM " + cfg.Table + " WHERE processed_at < " + cfg.Placeholder.format(1),
+	}
+}
+
+// Contains reports whether the inbox table has a row for the given message ID and group.
+func (s SQLStore) Contains(ctx context.Context, group, messageID string) (bool, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, s.selectQuery, group, messageID).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Save inserts a row for the given message ID and group into the inbox table. Saving a message ID twice (e.g.
+// concurrent deliveries of a message) keeps the existing row.
+func (s SQLStore) Save(ctx context.Context, group, messageID string) error {
+	_, err := s.db.ExecContext(ctx, s.insertQuery, group, messageID, time.Now().UnixNano())
+	if err == nil {
+		return nil
+	}
+	// unique constraint violations are database-specific, so the row is looked up instead
+	if processed, errContains := s.Contains(ctx, group, messageID); errContains == nil && processed {
+		return nil
+	}
+	return err
+}
+
+// Purge deletes every row processed before the given time, returning the number of deleted rows.
+func (s SQLStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.deleteQuery, before.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
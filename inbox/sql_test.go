@@ -0,0 +1,60 @@
+package inbox_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/neutrinocorp/streams/inbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInboxSchema = `CREATE TABLE streams_inbox (
+	group_name   VARCHAR(255) NOT NULL,
+	message_id   VARCHAR(255) NOT NULL,
+	processed_at BIGINT NOT NULL,
+	PRIMARY KEY (group_name, message_id)
+)`
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "inbox.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	_, err = db.Exec(testInboxSchema)
+	require.NoError(t, err)
+	return db
+}
+
+func TestSQLStore(t *testing.T) {
+	db := newTestDB(t)
+	store := inbox.NewSQLStore(db, inbox.Config{})
+	ctx := context.Background()
+	ok, err := store.Contains(ctx, "foo-group", "1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Save(ctx, "foo-group", "1"))
+	ok, err = store.Contains(ctx, "foo-group", "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = store.Contains(ctx, "bar-group", "1")
+	assert.False(t, ok)
+	// saving twice keeps the row
+	assert.NoError(t, store.Save(ctx, "foo-group", "1"))
+	assert.Error(t, inbox.NewSQLStore(db, inbox.Config{Table: "missing_inbox"}).Save(ctx, "foo-group", "1"))
+
+	n, err := store.Purge(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = store.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	ok, _ = store.Contains(ctx, "foo-group", "1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,11 @@
+package inbox
+
+import "context"
+
+// DedupeStore is a storage of message IDs processed by each reader group.
+type DedupeStore interface {
+	// Contains reports whether the given message ID was processed by the given group.
+	Contains(ctx context.Context, group, messageID string) (bool, error)
+	// Save records the given message ID as processed by the given group.
+	Save(ctx context.Context, group, messageID string) error
+}
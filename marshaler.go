@@ -30,6 +30,16 @@ type Marshaler interface {
 	ContentType() string
 }
 
+// HeadersMarshaler is a Marshaler describing its output through message headers (e.g. compression algorithm or
+// encryption key ID), so consumers know how data was encoded. Hub adds these headers to every written message.
+//
+// Marshaler decorators SHOULD include the headers of the decorated Marshaler.
+type HeadersMarshaler interface {
+	Marshaler
+	// Headers retrieves the headers describing the marshaled data.
+	Headers() map[string]string
+}
+
 // FailingMarshalerNoop the no-operation failing Marshaler
 //
 // For testing purposes only
@@ -37,6 +37,28 @@ type Message struct {
 	// Streamhub fields
 	CorrelationID string `json:"correlation_id"`
 	CausationID   string `json:"causation_id"`
+	// PartitionKey ordering key of the message (CloudEvents partitioning extension). Messages sharing a key are
+	// delivered in the same order they were written by drivers supporting ordering (e.g. Amazon SQS FIFO queues).
+	//
+	// Set by writers using either the StreamMetadata, the Partitioned interface or the WithPartitionKey WriteOption.
+	PartitionKey string `json:"partitionkey,omitempty"`
+	// DeduplicationID unique identifier used by drivers supporting deduplication (e.g. Amazon SQS FIFO queues) to
+	// discard duplicated messages.
+	//
+	// Set by writers using either the StreamMetadata, the Deduplicated interface or the WithDeduplicationID
+	// WriteOption.
+	DeduplicationID string `json:"deduplicationid,omitempty"`
+	// DeliverAt time (RFC 3339) before which the message MUST NOT be delivered to readers. Drivers supporting delayed
+	// delivery (e.g. Amazon SQS, in-memory) hold the message until then; see Scheduler for longer delays.
+	//
+	// Set by writers using either the WithDeliverAt or WithDelay WriteOption.
+	DeliverAt string `json:"deliverat,omitempty"`
+	// Headers extension attributes of the message (CloudEvents extensions) such as tenant id, trace parent or
+	// partition key. Keys SHOULD follow CloudEvents attribute naming conventions (lower-case alphanumeric characters
+	// only).
+	//
+	// Set by writers using either the WithHeaders WriteOption or ContextWithHeaders.
+	Headers map[string]string `json:"headers,omitempty"`
 
 	// consumer-only fields
 
@@ -46,6 +68,9 @@ type Message struct {
 	// GroupName name of the reader group (aka. consumer group). This field is ONLY available for usage
 	// from ReaderNode(s).
 	GroupName string `json:"-"`
+	// Acknowledger settles the message explicitly; nil if the Reader driver does not support it. This field is ONLY
+	// available for usage from ReaderNode(s).
+	Acknowledger Acknowledger `json:"-"`
 }
 
 // NewMessageArgs arguments required by NewMessage function to operate.
@@ -81,6 +106,22 @@ func NewMessage(args NewMessageArgs) Message {
 	}
 }
 
+// Delay retrieves the remaining time until the message delivery time (DeliverAt). Returns zero if the message has no
+// delivery time (or a malformed one) or if it was already reached.
+func (m Message) Delay() time.Duration {
+	if m.DeliverAt == "" {
+		return 0
+	}
+	deliverAt, err := time.Parse(time.RFC3339Nano, m.DeliverAt)
+	if err != nil {
+		return 0
+	}
+	if delay := time.Until(deliverAt); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
 func newMessageType(source, stream, version string) string {
 	buff := strings.Builder{}
 	sourceHasPrefix := strings.HasPrefix(stream, source)
@@ -2,6 +2,7 @@ package streams_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/neutrinocorp/streams"
 	"github.com/stretchr/testify/assert"
@@ -220,3 +221,11 @@ func BenchmarkNewMessage(b *testing.B) {
 		})
 	}
 }
+
+func TestMessage_Delay(t *testing.T) {
+	assert.Zero(t, streams.Message{}.Delay())
+	assert.Zero(t, streams.Message{DeliverAt: "tomorrow"}.Delay())
+	assert.Zero(t, streams.Message{DeliverAt: time.Now().Add(-time.Minute).Format(time.RFC3339Nano)}.Delay())
+	delay := streams.Message{DeliverAt: time.Now().Add(time.Minute).Format(time.RFC3339)}.Delay()
+	assert.True(t, delay > time.Second*58 && delay <= time.Minute, delay)
+}
@@ -0,0 +1,59 @@
+package outbox
+
+import (
+	"strconv"
+	"time"
+)
+
+// DefaultTable default name of the outbox table.
+const DefaultTable = "streams_outbox"
+
+// Placeholder query parameter placeholder format of a database.
+type Placeholder uint8
+
+const (
+	// QuestionPlaceholder uses ? placeholders (e.g. MySQL, SQLite).
+	QuestionPlaceholder Placeholder = iota
+	// DollarPlaceholder uses $N placeholders (e.g. PostgreSQL).
+	DollarPlaceholder
+)
+
+// format retrieves the placeholder of the n-th (starting from 1) query parameter.
+func (p Placeholder) format(n int) string {
+	if p == DollarPlaceholder {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// Config outbox Writer and Relay configuration.
+type Config struct {
+	// Table name of the outbox table.
+	Table       string
+	Placeholder Placeholder
+	// BatchSize maximum number of rows a Relay forwards on each polling cycle.
+	BatchSize int
+	// PollInterval duration a Relay waits between polling cycles.
+	PollInterval time.Duration
+}
+
+// DefaultConfig default outbox configuration.
+var DefaultConfig = Config{
+	Table:        DefaultTable,
+	Placeholder:  QuestionPlaceholder,
+	BatchSize:    100,
+	PollInterval: time.Second,
+}
+
+func (c Config) withDefaults() Config {
+	if c.Table == "" {
+		c.Table = DefaultConfig.Table
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultConfig.BatchSize
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = DefaultConfig.PollInterval
+	}
+	return c
+}
@@ -0,0 +1,30 @@
+// Package outbox contains the transactional outbox pattern implementation for Streamhub-based programs.
+//
+// Messages are written into an outbox table using the same database transaction as the program's state changes, so
+// both get committed (or rolled back) together. Later, a Relay forwards outbox rows to the actual streams.Writer with
+// at-least-once delivery.
+//
+// The outbox table is expected to have the following columns (types might vary depending on the database):
+//
+//	CREATE TABLE streams_outbox (
+//		id         VARCHAR(255) PRIMARY KEY, -- message id
+//		stream     VARCHAR(255) NOT NULL,
+//		payload    BLOB NOT NULL,            -- JSON-encoded streams.Message
+//		created_at BIGINT NOT NULL,          -- unix time in nanoseconds
+//		sent_at    BIGINT NULL               -- unix time in nanoseconds
+//	);
+//	CREATE INDEX streams_outbox_pending ON streams_outbox (sent_at, created_at);
+//
+// Moreover, a Scheduler stores messages with a delivery time until it is reached, forwarding them afterwards. The
+// schedule table is expected to have the following columns:
+//
+//	CREATE TABLE streams_scheduled (
+//		id         VARCHAR(255) PRIMARY KEY, -- message id
+//		stream     VARCHAR(255) NOT NULL,
+//		payload    BLOB NOT NULL,            -- JSON-encoded streams.Message
+//		deliver_at BIGINT NOT NULL,          -- unix time in nanoseconds
+//		created_at BIGINT NOT NULL,          -- unix time in nanoseconds
+//		sent_at    BIGINT NULL               -- unix time in nanoseconds
+//	);
+//	CREATE INDEX streams_scheduled_pending ON streams_scheduled (sent_at, deliver_at);
+package outbox
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/neutrinocorp/streams"
+)
+
+// Relay forwards pending outbox rows to a streams.Writer, marking them as sent afterwards.
+//
+// Rows are forwarded in insertion order with at-least-once delivery: if marking a row fails (or the program crashes
+// in between), the row gets forwarded again. Thus, running a single Relay per outbox table is recommended.
+type Relay struct {
+	db     *sql.DB
+	writer streams.Writer
+	config Config
+
+	selectQuery string
+	// selectArgs retrieves the arguments of selectQuery on each polling cycle; nil if it has none
+	selectArgs func() []interface{}
+	markQuery  string
+}
+
+// NewRelay allocates a new Relay ready to forward rows from the given database to the given Writer.
+func NewRelay(db *sql.DB, w streams.Writer, cfg Config) *Relay {
+	cfg = cfg.withDefaults()
+	return &Relay{
+		db:     db,
+		writer: w,
+		config: cfg,
+		selectQuery: "SELECT id, payload FROM " + cfg.Table + " WHERE sent_at IS NULL ORDER BY created_at, id LIMIT " +
+			strconv.Itoa(cfg.BatchSize),
+		markQuery: "UPDATE " + cfg.Table + " SET sent_at = " + cfg.Placeholder.format(1) + " WHERE id = " +
+			cfg.Placeholder.format(2),
+	}
+}
+
+// Run forwards pending rows periodically until the given context is canceled.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		// drain the outbox before waiting for the next cycle
+		for {
+			forwarded, err := r.Forward(ctx)
+			if err != nil || forwarded < r.config.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+type pendingRow struct {
+	id      string
+	message streams.Message
+}
+
+// Forward writes a batch of pending rows, retrieving the number of rows forwarded and marked as sent.
+//
+// Stops at the first failed row, so rows are never forwarded out of order.
+func (r *Relay) Forward(ctx context.Context) (int, error) {
+	rows, err := r.pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, row := range rows {
+		if err = r.writer.Write(ctx, row.message); err != nil {
+			return i, err
+		}
+		if _, err = r.db.ExecContext(ctx, r.markQuery, time.Now().UnixNano(), row.id); err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
+
+func (r *Relay) pending(ctx context.Context) ([]pendingRow, error) {
+	var args []interface{}
+	if r.selectArgs != nil {
+		args = r.selectArgs()
+	}
+	rows, err := r.db.QueryContext(ctx, r.selectQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := make([]pendingRow, 0, r.config.BatchSize)
+	for rows.Next() {
+		var (
+			row     pendingRow
+			payload []byte
+		)
+		if err = rows.Scan(&row.id, &payload); err != nil {
+			return nil, err
+		}
+		if err = jsoniter.Unmarshal(payload, &row.message); err != nil {
+			return nil, err
+		}
+		pending = append(pending, row)
+	}
+	return pending, rows.Err()
+}
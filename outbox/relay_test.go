@@ -0,0 +1,113 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingWriter stores written messages, failing for message IDs listed in failing.
+type recordingWriter struct {
+	mu       sync.Mutex
+	written  []streams.Message
+	failing  map[string]bool
+	attempts int
+}
+
+func (w *recordingWriter) Write(_ context.Context, message streams.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.attempts++
+	if w.failing[message.ID] {
+		return errors.New("generic error")
+	}
+	w.written = append(w.written, message)
+	return nil
+}
+
+func (w *recordingWriter) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	for i, msg := range messages {
+		res[i].Err = w.Write(ctx, msg)
+	}
+	return res, res.Err()
+}
+
+func (w *recordingWriter) ids() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ids := make([]string, 0, len(w.written))
+	for _, msg := range w.written {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+// writeOutbox inserts messages with the given IDs into the outbox table within a single transaction.
+func writeOutbox(t *testing.T, db *sql.DB, ids ...string) {
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	ctx := outbox.ContextWithTx(context.Background(), tx)
+	w := outbox.NewWriter(outbox.DefaultConfig)
+	for _, id := range ids {
+		require.NoError(t, w.Write(ctx, streams.Message{ID: id, Stream: "foo-stream", Data: []byte(id)}))
+	}
+	require.NoError(t, tx.Commit())
+}
+
+func TestRelay_Forward(t *testing.T) {
+	db := newTestDB(t)
+	writeOutbox(t, db, "1", "2", "3")
+
+	dst := &recordingWriter{failing: map[string]bool{"2": true}}
+	relay := outbox.NewRelay(db, dst, outbox.Config{BatchSize: 10})
+	forwarded, err := relay.Forward(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, forwarded)
+	assert.Equal(t, []string{"1"}, dst.ids())
+	assert.Equal(t, 2, countRows(t, db, "SELECT COUNT(*) FROM streams_outbox WHERE sent_at IS NULL"))
+
+	// failed rows are forwarded again in order
+	dst.failing = nil
+	forwarded, err = relay.Forward(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, forwarded)
+	assert.Equal(t, []string{"1", "2", "3"}, dst.ids())
+	assert.Equal(t, []byte("3"), dst.written[2].Data)
+	assert.Equal(t, 0, countRows(t, db, "SELECT COUNT(*) FROM streams_outbox WHERE sent_at IS NULL"))
+
+	forwarded, err = relay.Forward(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, forwarded)
+}
+
+func TestRelay_Run(t *testing.T) {
+	db := newTestDB(t)
+	dst := &recordingWriter{}
+	relay := outbox.NewRelay(db, dst, outbox.Config{
+		BatchSize:    2,
+		PollInterval: time.Millisecond * 10,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	errRun := make(chan error, 1)
+	go func() {
+		errRun <- relay.Run(ctx)
+	}()
+
+	writeOutbox(t, db, "1", "2", "3", "4", "5")
+	assert.Eventually(t, func() bool {
+		return len(dst.ids()) == 5
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, dst.ids())
+
+	cancel()
+	assert.ErrorIs(t, <-errRun, context.Canceled)
+}
@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/neutrinocorp/streams"
+)
+
+// DefaultScheduleTable default name of the table holding scheduled messages.
+const DefaultScheduleTable = "streams_scheduled"
+
+// Scheduler is the streams.Scheduler database implementation.
+//
+// Messages are inserted into the schedule table along their delivery time (streams.Message DeliverAt), using the
+// database transaction found in the context if any (see ContextWithTx). Scheduler runs a Relay forwarding messages
+// to the actual streams.Writer once their delivery time is reached, with at-least-once delivery.
+type Scheduler struct {
+	*Relay
+	db          *sql.DB
+	insertQuery string
+}
+
+var _ streams.Scheduler = Scheduler{}
+
+// NewScheduler allocates a new Scheduler ready to store messages into the given database and forward them to the
+// given Writer. Uses DefaultScheduleTable if the configuration has no table.
+func NewScheduler(db *sql.DB, w streams.Writer, cfg Config) Scheduler {
+	if cfg.Table == "" {
+		cfg.Table = DefaultScheduleTable
+	}
+	cfg = cfg.withDefaults()
+	relay := NewRelay(db, w, cfg)
+	relay.selectQuery = "SELECT id, payload FROM " + cfg.Table + " WHERE sent_at IS NULL AND deliver_at <= " +
+		cfg.Placeholder.format(1) + " ORDER BY deliver_at, id LIMIT " + strconv.Itoa(cfg.BatchSize)
+	relay.selectArgs = func() []interface{} {
+		return []interface{}{time.Now().UnixNano()}
+	}
+	return Scheduler{
+		Relay: relay,
+		db:    db,
+		insertQuery: "INSERT INTO " + cfg.Table + " (id, stream, payload, deliver_at, created_at) VALUES (" +
+			cfg.Placeholder.format(1) + ", " + cfg.Placeholder.format(2) + ", " +
+			cfg.Placeholder.format(3) + ", " + cfg.Placeholder.format(4) + ", " +
+			cfg.Placeholder.format(5) + ")",
+	}
+}
+
+// Schedule inserts the given message into the schedule table. Messages without a delivery time are forwarded on
+// the next polling cycle.
+func (s Scheduler) Schedule(ctx context.Context, message streams.Message) error {
+	payload, err := jsoniter.Marshal(message)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	deliverAt := now.Add(message.Delay())
+	args := []interface{}{message.ID, message.Stream, payload, deliverAt.UnixNano(), now.UnixNano()}
+	if tx, ok := TxFromContext(ctx); ok {
+		_, err = tx.ExecContext(ctx, s.insertQuery, args...)
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.insertQuery, args...)
+	return err
+}
@@ -0,0 +1,59 @@
+package outbox_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScheduleSchema = `CREATE TABLE streams_scheduled (
+	id         VARCHAR(255) PRIMARY KEY,
+	stream     VARCHAR(255) NOT NULL,
+	payload    BLOB NOT NULL,
+	deliver_at BIGINT NOT NULL,
+	created_at BIGINT NOT NULL,
+	sent_at    BIGINT NULL
+)`
+
+func TestScheduler(t *testing.T) {
+	db := newTestDB(t)
+	_, err := db.Exec(testScheduleSchema)
+	require.NoError(t, err)
+
+	dst := &recordingWriter{}
+	scheduler := outbox.NewScheduler(db, dst, outbox.Config{BatchSize: 10})
+	deliverAt := func(d time.Duration) string {
+		return time.Now().Add(d).UTC().Format(time.RFC3339Nano)
+	}
+	ctx := context.Background()
+	require.NoError(t, scheduler.Schedule(ctx, streams.Message{ID: "1", Stream: "foo-stream",
+		DeliverAt: deliverAt(time.Millisecond * 100)}))
+	require.NoError(t, scheduler.Schedule(ctx, streams.Message{ID: "2", Stream: "foo-stream",
+		DeliverAt: deliverAt(time.Hour)}))
+
+	// scheduled messages are committed along the caller transaction
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, scheduler.Schedule(outbox.ContextWithTx(ctx, tx), streams.Message{ID: "3",
+		Stream: "foo-stream", DeliverAt: deliverAt(time.Millisecond * 50)}))
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, 3, countRows(t, db, "SELECT COUNT(*) FROM streams_scheduled"))
+
+	forwarded, err := scheduler.Forward(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, forwarded)
+
+	// messages are forwarded in delivery order
+	time.Sleep(time.Millisecond * 100)
+	forwarded, err = scheduler.Forward(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, forwarded)
+	assert.Equal(t, []string{"3", "1"}, dst.ids())
+	assert.Zero(t, dst.written[0].Delay())
+	assert.Equal(t, 1, countRows(t, db, "SELECT COUNT(*) FROM streams_scheduled WHERE sent_at IS NULL"))
+}
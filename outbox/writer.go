@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/neutrinocorp/streams"
+)
+
+// ErrMissingTx no database transaction was found in the write context.
+var ErrMissingTx = errors.New("streams: Missing outbox database transaction in context")
+
+type contextKey int
+
+const contextTx contextKey = iota
+
+// ContextWithTx sets the database transaction the outbox Writer will use to insert messages.
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, contextTx, tx)
+}
+
+// TxFromContext retrieves the database transaction set using ContextWithTx, if any.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(contextTx).(*sql.Tx)
+	return tx, ok && tx != nil
+}
+
+// Writer is the streams.Writer transactional outbox implementation.
+//
+// Messages are inserted into the outbox table through the database transaction found in the write context (see
+// ContextWithTx), so they are committed along the caller's state changes. The caller owns the transaction; Writer
+// never commits nor rolls it back.
+type Writer struct {
+	insertQuery string
+}
+
+var _ streams.Writer = Writer{}
+
+// NewWriter allocates a new Writer ready to be used.
+func NewWriter(cfg Config) Writer {
+	cfg = cfg.withDefaults()
+	return Writer{
+		insertQuery: "INSERT INTO " + cfg.Table + " (id, stream, payload, created_at) VALUES (" +
+			cfg.Placeholder.format(1) + ", " + cfg.Placeholder.format(2) + ", " +
+			cfg.Placeholder.format(3) + ", " + cfg.Placeholder.format(4) + ")",
+	}
+}
+
+// Write inserts the given message into the outbox table.
+func (w Writer) Write(ctx context.Context, message streams.Message) error {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return ErrMissingTx
+	}
+	return w.insert(ctx, tx, message)
+}
+
+// WriteBatch inserts the given set of messages into the outbox table.
+//
+// Stops at the first failed insert as the transaction is most likely aborted. Thus, the remaining messages are
+// marked as failed with the same error.
+func (w Writer) WriteBatch(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	res := streams.NewBatchResult(messages)
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		res.FailAll(ErrMissingTx)
+		return res, ErrMissingTx
+	}
+	for i, msg := range messages {
+		if err := w.insert(ctx, tx, msg); err != nil {
+			res[i:].FailAll(err)
+			break
+		}
+	}
+	return res, res.Err()
+}
+
+func (w Writer) insert(ctx context.Context, tx *sql.Tx, message streams.Message) error {
+	payload, err := jsoniter.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, w.insertQuery, message.ID, message.Stream, payload, time.Now().UnixNano())
+	return err
+}
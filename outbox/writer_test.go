@@ -0,0 +1,72 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/neutrinocorp/streams"
+	"github.com/neutrinocorp/streams/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOutboxSchema = `CREATE TABLE streams_outbox (
+	id         VARCHAR(255) PRIMARY KEY,
+	stream     VARCHAR(255) NOT NULL,
+	payload    BLOB NOT NULL,
+	created_at BIGINT NOT NULL,
+	sent_at    BIGINT NULL
+)`
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "outbox.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	_, err = db.Exec(testOutboxSchema)
+	require.NoError(t, err)
+	return db
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	var n int
+	require.NoError(t, db.QueryRow(query).Scan(&n))
+	return n
+}
+
+func TestWriter_Write(t *testing.T) {
+	db := newTestDB(t)
+	w := outbox.NewWriter(outbox.DefaultConfig)
+	assert.ErrorIs(t, w.Write(context.Background(), streams.Message{ID: "1"}), outbox.ErrMissingTx)
+
+	// rolled back messages are discarded along the transaction
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, w.Write(outbox.ContextWithTx(context.Background(), tx), streams.Message{ID: "1"}))
+	require.NoError(t, tx.Rollback())
+	assert.Equal(t, 0, countRows(t, db, "SELECT COUNT(*) FROM streams_outbox"))
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	ctx := outbox.ContextWithTx(context.Background(), tx)
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "1", Stream: "foo-stream", Data: []byte("foo")}))
+	res, err := w.WriteBatch(ctx, streams.Message{ID: "2", Stream: "foo-stream"},
+		streams.Message{ID: "1", Stream: "foo-stream"})
+	// duplicated id
+	assert.Error(t, err)
+	assert.Equal(t, uint32(1), res.Succeeded())
+	assert.Error(t, res[1].Err)
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, 2, countRows(t, db, "SELECT COUNT(*) FROM streams_outbox WHERE sent_at IS NULL"))
+}
+
+func TestTxFromContext(t *testing.T) {
+	_, ok := outbox.TxFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = outbox.TxFromContext(outbox.ContextWithTx(context.Background(), nil))
+	assert.False(t, ok)
+}
@@ -7,6 +7,7 @@ import (
 // ReaderHandleFunc is the execution process triggered when a message is received from a stream.
 //
 // Returns an error to indicate the process has failed so Hub will retry the processing using exponential backoff.
+// Wrap the error using Permanent to indicate the process will never succeed, so Hub stops retrying at once.
 type ReaderHandleFunc func(context.Context, Message) error
 
 // ReaderHandler is a wrapping structure of the ReadFunc handler for complex data processing scenarios.
@@ -0,0 +1,201 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultBatchSize default maximum number of messages processed at once by a ReaderBatchHandleFunc.
+	DefaultBatchSize = 10
+	// DefaultBatchLinger default maximum duration a ReaderBatchHandleFunc waits for a batch to be filled up.
+	DefaultBatchLinger = time.Millisecond * 100
+)
+
+// ReaderBatchHandleFunc is the execution process triggered when a batch of messages is received from a stream.
+//
+// Returns an error to indicate the whole batch has failed. Return a BatchItemErrors to indicate only some messages
+// have failed instead; remaining messages are considered processed. Failed messages are retried (and sent to the
+// dead-letter stream, if any) individually by the Hub, joining later batches.
+//
+// The given context is not tied to any single message: it is canceled once every message of the batch stopped waiting
+// (e.g. their retry timeout elapsed) and holds no message-scoped values, so these (e.g. correlation IDs or
+// Acknowledger) must be taken from each Message.
+type ReaderBatchHandleFunc func(context.Context, []Message) error
+
+// BatchItemErrors is the set of errors of the messages which failed to be processed by a ReaderBatchHandleFunc,
+// indexed by the position of each message in the batch.
+type BatchItemErrors map[int]error
+
+var _ error = BatchItemErrors{}
+
+// Error retrieves the text of every inner error prefixed with its batch index and separated by a semicolon.
+func (e BatchItemErrors) Error() string {
+	indexes := make([]int, 0, len(e))
+	for i := range e {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	buff := strings.Builder{}
+	for i, idx := range indexes {
+		if i > 0 {
+			buff.WriteString("; ")
+		}
+		buff.WriteString(strconv.Itoa(idx))
+		buff.WriteString(": ")
+		buff.WriteString(e[idx].Error())
+	}
+	return buff.String()
+}
+
+// Is reports whether any inner error matches the given target (using errors.Is).
+func (e BatchItemErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// readerBatcher accumulates messages handled concurrently by a ReaderNode's driver into batches for a
+// ReaderBatchHandleFunc. A batch is processed when it is full or when its linger duration expires.
+//
+// As every message waits for its batch to be processed, ReaderBehaviour(s) keep being applied per message. A message
+// whose context is done while its batch is pending leaves the batch, so it is not processed by both the batch and
+// its retry; once the batch is being processed, every message waits for the result.
+type readerBatcher struct {
+	handler ReaderBatchHandleFunc
+	size    int
+	linger  time.Duration
+
+	mu      sync.Mutex
+	pending *readerBatch
+}
+
+type readerBatch struct {
+	items []*readerBatchItem
+	timer *time.Timer
+	done  chan struct{}
+}
+
+type readerBatchItem struct {
+	ctx     context.Context
+	message Message
+	err     error
+}
+
+func newReaderBatcher(handler ReaderBatchHandleFunc, size int, linger time.Duration) *readerBatcher {
+	return &readerBatcher{
+		handler: handler,
+		size:    size,
+		linger:  linger,
+	}
+}
+
+// handle adds the given message into the pending batch and waits for the batch to be processed.
+func (b *readerBatcher) handle(ctx context.Context, message Message) error {
+	item := &readerBatchItem{
+		ctx:     ctx,
+		message: message,
+	}
+	b.mu.Lock()
+	batch := b.pending
+	if batch == nil {
+		batch = &readerBatch{
+			items: make([]*readerBatchItem, 0, b.size),
+			done:  make(chan struct{}),
+		}
+		batch.timer = time.AfterFunc(b.linger, func() {
+			b.flush(batch)
+		})
+		b.pending = batch
+	}
+	batch.items = append(batch.items, item)
+	full := len(batch.items) >= b.size
+	if full {
+		batch.timer.Stop()
+		b.pending = nil
+	}
+	b.mu.Unlock()
+
+	if full {
+		b.process(batch)
+	}
+	select {
+	case <-batch.done:
+		return item.err
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	if b.pending != batch {
+		// batch is being processed
+		b.mu.Unlock()
+		<-batch.done
+		return item.err
+	}
+	for i, pendingItem := range batch.items {
+		if pendingItem == item {
+			batch.items = append(batch.items[:i], batch.items[i+1:]...)
+			break
+		}
+	}
+	b.mu.Unlock()
+	return ctx.Err()
+}
+
+// flush processes the given batch if it is still pending.
+func (b *readerBatcher) flush(batch *readerBatch) {
+	b.mu.Lock()
+	if b.pending != batch {
+		b.mu.Unlock()
+		return
+	}
+	b.pending = nil
+	b.mu.Unlock()
+	b.process(batch)
+}
+
+func (b *readerBatcher) process(batch *readerBatch) {
+	defer close(batch.done)
+	if len(batch.items) == 0 {
+		// every message left the batch
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for _, item := range batch.items {
+			select {
+			case <-item.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+
+	messages := make([]Message, len(batch.items))
+	for i, item := range batch.items {
+		messages[i] = item.message
+	}
+	err := b.handler(ctx, messages)
+	var itemErrs BatchItemErrors
+	if errors.As(err, &itemErrs) {
+		for i, errItem := range itemErrs {
+			if i >= 0 && i < len(batch.items) {
+				batch.items[i].err = errItem
+			}
+		}
+	} else if err != nil {
+		for _, item := range batch.items {
+			item.err = err
+		}
+	}
+}
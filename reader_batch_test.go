@@ -0,0 +1,142 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchItemErrors(t *testing.T) {
+	errFoo := errors.New("foo error")
+	err := BatchItemErrors{3: errors.New("bar error"), 1: errFoo}
+	assert.EqualError(t, err, "1: foo error; 3: bar error")
+	assert.ErrorIs(t, err, errFoo)
+	assert.False(t, errors.Is(err, ErrHubClosed))
+}
+
+// handleConcurrently executes the given handler for each of the given messages concurrently, returning the error
+// of each execution indexed by message ID.
+func handleConcurrently(h ReaderHandleFunc, ids ...string) map[string]error {
+	mu := sync.Mutex{}
+	errs := make(map[string]error, len(ids))
+	wg := sync.WaitGroup{}
+	wg.Add(len(ids))
+	for _, id := range ids {
+		go func(id string) {
+			defer wg.Done()
+			err := h(context.Background(), Message{ID: id})
+			mu.Lock()
+			errs[id] = err
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestReaderBatcher_Handle(t *testing.T) {
+	mu := sync.Mutex{}
+	var batches [][]Message
+	b := newReaderBatcher(func(_ context.Context, messages []Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, messages)
+		return nil
+	}, 3, time.Hour)
+
+	// full batches are processed at once
+	errs := handleConcurrently(b.handle, "1", "2", "3")
+	assert.Equal(t, map[string]error{"1": nil, "2": nil, "3": nil}, errs)
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0], 3)
+
+	// incomplete batches are processed after linger
+	b.linger = time.Millisecond * 10
+	startTime := time.Now()
+	errs = handleConcurrently(b.handle, "4", "5")
+	assert.GreaterOrEqual(t, time.Since(startTime), b.linger)
+	assert.Len(t, errs, 2)
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[1], 2)
+}
+
+func TestReaderBatcher_HandleErrors(t *testing.T) {
+	errFoo := errors.New("foo error")
+	b := newReaderBatcher(func(_ context.Context, messages []Message) error {
+		itemErrs := BatchItemErrors{}
+		for i, msg := range messages {
+			if id, _ := strconv.Atoi(msg.ID); id%2 == 0 {
+				itemErrs[i] = errFoo
+			}
+		}
+		return itemErrs
+	}, 4, time.Hour)
+	errs := handleConcurrently(b.handle, "1", "2", "3", "4")
+	assert.Equal(t, map[string]error{"1": nil, "2": errFoo, "3": nil, "4": errFoo}, errs)
+
+	// whole batch failures are reported to every message
+	b.handler = func(_ context.Context, _ []Message) error {
+		return errFoo
+	}
+	errs = handleConcurrently(b.handle, "1", "2", "3", "4")
+	assert.Equal(t, map[string]error{"1": errFoo, "2": errFoo, "3": errFoo, "4": errFoo}, errs)
+
+	// messages stop waiting once their context is done
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, b.handle(ctx, Message{ID: "5"}), context.DeadlineExceeded)
+}
+
+func TestReaderBatcher_HandleTimeout(t *testing.T) {
+	batches := make(chan []Message, 1)
+	b := newReaderBatcher(func(_ context.Context, messages []Message) error {
+		batches <- messages
+		return nil
+	}, 2, time.Hour)
+
+	// messages timing out while their batch is pending leave the batch, so they are not processed twice
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, b.handle(ctx, Message{ID: "1"}), context.DeadlineExceeded)
+	errs := handleConcurrently(b.handle, "2", "3")
+	assert.Equal(t, map[string]error{"2": nil, "3": nil}, errs)
+	batch := <-batches
+	require.Len(t, batch, 2)
+	assert.NotEqual(t, "1", batch[0].ID)
+	assert.NotEqual(t, "1", batch[1].ID)
+}
+
+func TestReaderBatcher_HandleContext(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var batchErr error
+	b := newReaderBatcher(func(ctx context.Context, _ []Message) error {
+		close(started)
+		<-release
+		batchErr = ctx.Err()
+		return nil
+	}, 2, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		errs <- b.handle(ctx, Message{ID: "1"})
+	}()
+	go func() {
+		errs <- b.handle(context.Background(), Message{ID: "2"})
+	}()
+	<-started
+	// the batch is not tied to the context of any single message and messages being processed wait for the result
+	cancel()
+	time.Sleep(time.Millisecond * 10)
+	close(release)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, batchErr)
+}
@@ -2,8 +2,11 @@ package streams
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
 	"github.com/cenkalti/backoff/v4"
+	"golang.org/x/time/rate"
 )
 
 // ReaderBehaviour is a middleware function with extra functionality which will be executed prior a ReaderHandleFunc
@@ -16,6 +19,12 @@ import (
 //
 // - Retry backoff
 //
+// - Dead-letter stream (*only if ReaderNode has a dead-letter stream)
+//
+// - Rate limiting and maximum in-flight messages (*only if ReaderNode has a rate limit or in-flight limit)
+//
+// - Circuit breaker (*only if ReaderNode has a CircuitBreaker)
+//
 // - Correlation and causation ID injection
 //
 // - Consumer group injection
@@ -38,7 +47,11 @@ var ReaderBaseBehaviours = []ReaderBehaviour{
 	unmarshalReaderBehaviour,
 	injectGroupReaderBehaviour,
 	injectTxIDsReaderBehaviour,
+	circuitBreakerReaderBehaviour,
 	retryReaderBehaviour,
+	deadLetterReaderBehaviour,
+	maxInFlightReaderBehaviour,
+	rateLimitReaderBehaviour,
 }
 
 // ReaderBaseBehavioursNoUnmarshal default ReaderBehaviours without unmarshaling
@@ -47,39 +60,127 @@ var ReaderBaseBehaviours = []ReaderBehaviour{
 var ReaderBaseBehavioursNoUnmarshal = []ReaderBehaviour{
 	injectGroupReaderBehaviour,
 	injectTxIDsReaderBehaviour,
+	circuitBreakerReaderBehaviour,
 	retryReaderBehaviour,
+	deadLetterReaderBehaviour,
+	maxInFlightReaderBehaviour,
+	rateLimitReaderBehaviour,
 }
 
+// retryReaderBehaviour retries failed executions using exponential backoff. Executions failing with a
+// PermanentError or ErrCircuitOpen are not retried.
 var retryReaderBehaviour ReaderBehaviour = func(node *ReaderNode, _ *Hub, next ReaderHandleFunc) ReaderHandleFunc {
-	b := backoff.NewExponentialBackOff()
-	b.InitialInterval = node.RetryInitialInterval
-	b.MaxInterval = node.RetryMaxInterval
-	b.MaxElapsedTime = node.RetryTimeout
 	return func(ctx context.Context, message Message) error {
+		// backoff algorithms are stateful, so each execution requires its own instance
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = node.RetryInitialInterval
+		b.MaxInterval = node.RetryMaxInterval
+		b.MaxElapsedTime = node.RetryTimeout
+		attempts, _ := ctx.Value(contextRetryAttempts).(*int)
 		return backoff.Retry(func() error {
-			return next(ctx, message)
+			if attempts != nil {
+				*attempts++
+			}
+			err := next(ctx, message)
+			if IsPermanent(err) || errors.Is(err, ErrCircuitOpen) {
+				return backoff.Permanent(err)
+			}
+			return err
 		}, b)
 	}
 }
 
+const (
+	// HeaderDeadLetterError text of the error which made a message to be written into a dead-letter stream.
+	HeaderDeadLetterError = "dlqerror"
+	// HeaderDeadLetterAttempts number of processing attempts of a message written into a dead-letter stream.
+	HeaderDeadLetterAttempts = "dlqattempts"
+	// HeaderDeadLetterStream name of the stream a message written into a dead-letter stream was read from.
+	HeaderDeadLetterStream = "dlqstream"
+	// HeaderDeadLetterGroup name of the reader group which failed to process a message written into a dead-letter stream.
+	HeaderDeadLetterGroup = "dlqgroup"
+)
+
+type readerContextKey int
+
+const (
+	// contextRetryAttempts holds a counter of processing attempts made by the retry behaviour.
+	contextRetryAttempts readerContextKey = iota
+	// contextAcknowledger holds the Acknowledger of the message being processed.
+	contextAcknowledger
+)
+
+var deadLetterReaderBehaviour ReaderBehaviour = func(node *ReaderNode, h *Hub, next ReaderHandleFunc) ReaderHandleFunc {
+	if node.DeadLetterStream == "" {
+		return next
+	}
+	return func(ctx context.Context, message Message) error {
+		attempts := 0
+		err := next(context.WithValue(ctx, contextRetryAttempts, &attempts), message)
+		if err == nil {
+			return nil
+		} else if errors.Is(err, ErrCircuitOpen) {
+			// message was not processed, so it is left to the driver redelivery
+			return err
+		}
+
+		// processing context might be already expired as retries are bounded by the same timeout
+		writeCtx := ctx
+		if ctx.Err() != nil {
+			writeCtx = context.Background()
+		}
+		if errWrite := h.WriteRawMessage(writeCtx, newDeadLetterMessage(node, message, err, attempts)); errWrite != nil {
+			return MultiError{err, errWrite}
+		}
+		// handlers might not settle failed messages in manual acknowledgement mode
+		if ack, ok := AcknowledgerFromContext(ctx); ok {
+			_ = ack.Term(writeCtx)
+		}
+		return nil
+	}
+}
+
+// newDeadLetterMessage copies the given message, routing it to the ReaderNode's dead-letter stream and adding
+// headers describing the processing failure.
+func newDeadLetterMessage(node *ReaderNode, message Message, err error, attempts int) Message {
+	headers := make(map[string]string, len(message.Headers)+4)
+	for k, v := range message.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDeadLetterError] = err.Error()
+	headers[HeaderDeadLetterAttempts] = strconv.Itoa(attempts)
+	headers[HeaderDeadLetterStream] = message.Stream
+	headers[HeaderDeadLetterGroup] = node.Group
+
+	message.Stream = node.DeadLetterStream
+	message.Headers = headers
+	message.DecodedData = nil
+	message.GroupName = ""
+	message.Acknowledger = nil
+	return message
+}
+
 var unmarshalReaderBehaviour ReaderBehaviour = func(_ *ReaderNode, h *Hub, next ReaderHandleFunc) ReaderHandleFunc {
 	return func(ctx context.Context, message Message) error {
 		metadata, err := h.StreamRegistry.GetByStreamName(message.Stream)
 		if err != nil {
-			return err
+			return Permanent(err)
 		}
 		var schemaDef string
 		if h.SchemaRegistry != nil {
 			schemaDef, err = h.SchemaRegistry.GetSchemaDefinition(metadata.SchemaDefinitionName,
 				metadata.SchemaVersion)
-			if err != nil {
+			if errors.Is(err, ErrMissingSchemaDefinition) {
+				return Permanent(err)
+			} else if err != nil {
 				return err
 			}
 		}
 		if metadata.GoType != nil {
 			decodedData := metadata.GoType.New()
 			if err = h.Marshaler.Unmarshal(schemaDef, message.Data, decodedData); err != nil {
-				return err
+				// a message which could not be decoded will never be decoded, no matter how many times it is retried
+				return Permanent(err)
 			}
 			switch h.Marshaler.ContentType() {
 			case MarshalerProtoContentType:
@@ -106,3 +207,60 @@ var injectTxIDsReaderBehaviour ReaderBehaviour = func(_ *ReaderNode, h *Hub, nex
 		return next(ctxCausation, message)
 	}
 }
+
+// rateLimitReaderBehaviour waits for the ReaderNode's rate limit before every message. As it runs before the retry and
+// dead-letter behaviours, a message whose context is done while waiting is left to the driver redelivery instead of
+// being dead-lettered; retries do not count against the limit.
+var rateLimitReaderBehaviour ReaderBehaviour = func(node *ReaderNode, _ *Hub, next ReaderHandleFunc) ReaderHandleFunc {
+	if node.RateLimit <= 0 {
+		return next
+	}
+	burst := node.RateBurst
+	if burst <= 0 {
+		burst = 1
+	}
+	limiter := rate.NewLimiter(rate.Limit(node.RateLimit), burst)
+	return func(ctx context.Context, message Message) error {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		return next(ctx, message)
+	}
+}
+
+// maxInFlightReaderBehaviour bounds the number of messages processed concurrently by a ReaderNode. As it runs before
+// the retry and dead-letter behaviours, a message whose context is done while waiting is left to the driver
+// redelivery instead of being dead-lettered; messages hold their slot while waiting for a retry.
+var maxInFlightReaderBehaviour ReaderBehaviour = func(node *ReaderNode, _ *Hub, next ReaderHandleFunc) ReaderHandleFunc {
+	if node.MaxInFlight <= 0 {
+		return next
+	}
+	sem := make(chan struct{}, node.MaxInFlight)
+	return func(ctx context.Context, message Message) error {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-sem }()
+		return next(ctx, message)
+	}
+}
+
+// circuitBreakerReaderBehaviour waits for the ReaderNode's CircuitBreaker to allow every execution, recording its
+// result. As it runs within the retry behaviour, an open circuit pauses retries instead of exhausting them.
+var circuitBreakerReaderBehaviour ReaderBehaviour = func(node *ReaderNode, _ *Hub, next ReaderHandleFunc) ReaderHandleFunc {
+	if node.CircuitBreaker == nil {
+		return next
+	}
+	b := node.CircuitBreaker
+	return func(ctx context.Context, message Message) error {
+		generation, err := b.wait(ctx)
+		if err != nil {
+			return err
+		}
+		err = next(ctx, message)
+		b.done(generation, err)
+		return err
+	}
+}
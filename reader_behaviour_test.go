@@ -3,6 +3,8 @@ package streams
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -207,3 +209,271 @@ func TestReaderNodeHandlerBehaviour_TxInjector(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+type writerFuncHook func(context.Context, Message) error
+
+var _ Writer = writerFuncHook(nil)
+
+func (w writerFuncHook) Write(ctx context.Context, message Message) error {
+	return w(ctx, message)
+}
+
+func (w writerFuncHook) WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
+	res := NewBatchResult(messages)
+	for i, msg := range messages {
+		res[i].Err = w(ctx, msg)
+	}
+	return res, res.Err()
+}
+
+func TestReaderNodeHandlerBehaviour_DeadLetter(t *testing.T) {
+	var h ReaderHandleFunc = func(ctx context.Context, message Message) error {
+		return errors.New("generic error")
+	}
+	node := &ReaderNode{
+		Stream:               "foo-stream",
+		Group:                "foo-group",
+		RetryInitialInterval: time.Millisecond,
+		RetryMaxInterval:     time.Millisecond,
+		RetryTimeout:         time.Millisecond * 5,
+	}
+	var written []Message
+	hub := NewHub(WithWriter(writerFuncHook(func(_ context.Context, message Message) error {
+		written = append(written, message)
+		return nil
+	})))
+
+	// no dead-letter stream set, no-op
+	noDlqHandler := deadLetterReaderBehaviour(node, hub, retryReaderBehaviour(node, hub, h))
+	assert.Error(t, noDlqHandler(context.Background(), Message{}))
+	assert.Len(t, written, 0)
+
+	node.DeadLetterStream = "foo-stream-dlq"
+	h = deadLetterReaderBehaviour(node, hub, retryReaderBehaviour(node, hub, h))
+	err := h(context.Background(), Message{
+		ID:      "123",
+		Stream:  "foo-stream",
+		Data:    []byte("foo"),
+		Headers: map[string]string{"tenant": "bar"},
+	})
+	assert.NoError(t, err)
+	require.Len(t, written, 1)
+	assert.Equal(t, "123", written[0].ID)
+	assert.Equal(t, "foo-stream-dlq", written[0].Stream)
+	assert.Equal(t, []byte("foo"), written[0].Data)
+	assert.Equal(t, "bar", written[0].Headers["tenant"])
+	assert.Equal(t, "generic error", written[0].Headers[HeaderDeadLetterError])
+	assert.Equal(t, "foo-stream", written[0].Headers[HeaderDeadLetterStream])
+	assert.Equal(t, "foo-group", written[0].Headers[HeaderDeadLetterGroup])
+	attempts, err := strconv.Atoi(written[0].Headers[HeaderDeadLetterAttempts])
+	require.NoError(t, err)
+	assert.Greater(t, attempts, 1)
+
+	hub.Writer = writerFuncHook(func(_ context.Context, _ Message) error {
+		return errors.New("generic write error")
+	})
+	err = h(context.Background(), Message{Stream: "foo-stream"})
+	assert.EqualError(t, err, "generic error; generic write error")
+
+	// messages written into the dead-letter stream are terminated
+	hub.Writer = writerFuncHook(func(_ context.Context, _ Message) error {
+		return nil
+	})
+	ack := &acknowledgerRecorder{}
+	require.NoError(t, h(ContextWithAcknowledger(context.Background(), ack), Message{Stream: "foo-stream"}))
+	assert.Equal(t, []string{"term"}, ack.calls)
+}
+
+func TestReaderNodeHandlerBehaviour_RetryPermanent(t *testing.T) {
+	errPoison := errors.New("poison message")
+	calls := 0
+	var h ReaderHandleFunc = func(ctx context.Context, message Message) error {
+		calls++
+		return Permanent(errPoison)
+	}
+	baseOpts := &ReaderNode{
+		RetryInitialInterval: time.Millisecond,
+		RetryMaxInterval:     time.Millisecond,
+		RetryTimeout:         time.Second,
+	}
+	h = retryReaderBehaviour(baseOpts, nil, h)
+	err := h(context.Background(), Message{})
+	assert.ErrorIs(t, err, errPoison)
+	assert.True(t, IsPermanent(err))
+	assert.Equal(t, 1, calls)
+	assert.Nil(t, Permanent(nil))
+	assert.False(t, IsPermanent(errPoison))
+}
+
+func TestReaderNodeHandlerBehaviour_UnmarshalPermanent(t *testing.T) {
+	var h ReaderHandleFunc = func(ctx context.Context, message Message) error {
+		return nil
+	}
+	hub := NewHub()
+	h = unmarshalReaderBehaviour(&ReaderNode{}, hub, h)
+	err := h(context.Background(), Message{Stream: "foo-stream"})
+	assert.ErrorIs(t, err, ErrMissingStream)
+	assert.True(t, IsPermanent(err))
+
+	hub.StreamRegistry.SetByString("foo", StreamMetadata{
+		Stream: "foo-stream",
+		GoType: reflect2.TypeOf(fooMessage{}),
+	})
+	err = h(context.Background(), Message{Stream: "foo-stream", Data: []byte("not a json")})
+	assert.True(t, IsPermanent(err))
+}
+
+func TestReaderNodeHandlerBehaviour_RateLimit(t *testing.T) {
+	calls := 0
+	var h ReaderHandleFunc = func(ctx context.Context, message Message) error {
+		calls++
+		return nil
+	}
+	// no rate limit set, no-op
+	noLimitHandler := rateLimitReaderBehaviour(&ReaderNode{}, nil, h)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, noLimitHandler(context.Background(), Message{}))
+	}
+
+	h = rateLimitReaderBehaviour(&ReaderNode{RateLimit: 100, RateBurst: 2}, nil, h)
+	startTime := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, h(context.Background(), Message{}))
+	}
+	// burst is executed at once, remaining executions every 10ms
+	assert.GreaterOrEqual(t, time.Since(startTime), time.Millisecond*15)
+	assert.Equal(t, 14, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, h(ctx, Message{}), context.Canceled)
+	assert.Equal(t, 14, calls)
+}
+
+func TestReaderNodeHandlerBehaviour_MaxInFlight(t *testing.T) {
+	mu := sync.Mutex{}
+	inFlight, maxInFlight := 0, 0
+	var h ReaderHandleFunc = func(ctx context.Context, message Message) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond * 10)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	}
+	h = maxInFlightReaderBehaviour(&ReaderNode{MaxInFlight: 2}, nil, h)
+
+	wg := sync.WaitGroup{}
+	wg.Add(6)
+	for i := 0; i < 6; i++ {
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, h(context.Background(), Message{}))
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	// executions waiting for a slot stop once their context is done
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h(ctx, Message{}), context.DeadlineExceeded)
+	wg.Wait()
+	assert.Equal(t, 2, maxInFlight)
+}
+
+func TestReaderNodeHandlerBehaviour_MaxInFlightDeadLetter(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	node := &ReaderNode{
+		DeadLetterStream:     "foo-stream-dlq",
+		RetryInitialInterval: time.Millisecond,
+		RetryMaxInterval:     time.Millisecond,
+		RetryTimeout:         time.Millisecond * 20,
+		MaxInFlight:          1,
+		RateLimit:            1000,
+	}
+	var written []Message
+	hub := NewHub(WithWriter(writerFuncHook(func(_ context.Context, message Message) error {
+		written = append(written, message)
+		return nil
+	})))
+	var h ReaderHandleFunc = func(_ context.Context, _ Message) error {
+		calls++
+		<-release
+		return nil
+	}
+	for _, b := range ReaderBaseBehavioursNoUnmarshal {
+		h = b(node, hub, h)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- h(context.Background(), Message{Stream: "foo-stream"})
+	}()
+	time.Sleep(time.Millisecond * 5)
+	// messages waiting for a slot beyond their timeout are left to the driver redelivery, never dead-lettered
+	ctx, cancel := context.WithTimeout(context.Background(), node.RetryTimeout)
+	defer cancel()
+	assert.ErrorIs(t, h(ctx, Message{Stream: "foo-stream"}), context.DeadlineExceeded)
+	close(release)
+	assert.NoError(t, <-errs)
+	assert.Equal(t, 1, calls)
+	assert.Len(t, written, 0)
+}
+
+func TestReaderNodeHandlerBehaviour_CircuitBreaker(t *testing.T) {
+	calls := 0
+	var h ReaderHandleFunc = func(ctx context.Context, message Message) error {
+		calls++
+		if calls == 1 {
+			return errors.New("generic error")
+		}
+		return nil
+	}
+	node := &ReaderNode{
+		DeadLetterStream:     "foo-stream-dlq",
+		RetryInitialInterval: time.Millisecond,
+		RetryMaxInterval:     time.Millisecond,
+		RetryTimeout:         time.Second,
+		CircuitBreaker: NewCircuitBreaker("foo", WithFailureRatio(1, 1),
+			WithOpenTimeout(time.Millisecond*30)),
+	}
+	var written []Message
+	hub := NewHub(WithWriter(writerFuncHook(func(_ context.Context, message Message) error {
+		written = append(written, message)
+		return nil
+	})))
+	h = deadLetterReaderBehaviour(node, hub, retryReaderBehaviour(node, hub,
+		circuitBreakerReaderBehaviour(node, hub, h)))
+
+	// retries wait for the circuit to allow a trial execution
+	startTime := time.Now()
+	require.NoError(t, h(context.Background(), Message{Stream: "foo-stream"}))
+	assert.GreaterOrEqual(t, time.Since(startTime), time.Millisecond*30)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, CircuitClosed, node.CircuitBreaker.State())
+
+	// messages waiting beyond their timeout are neither retried nor dead-lettered
+	calls = 0
+	node.CircuitBreaker = NewCircuitBreaker("foo", WithFailureRatio(1, 1), WithOpenTimeout(time.Hour))
+	h = deadLetterReaderBehaviour(node, hub, retryReaderBehaviour(node, hub,
+		circuitBreakerReaderBehaviour(node, hub, func(_ context.Context, _ Message) error {
+			calls++
+			return errors.New("generic error")
+		})))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.ErrorIs(t, h(ctx, Message{Stream: "foo-stream"}), ErrCircuitOpen)
+	assert.Equal(t, 1, calls)
+	assert.Len(t, written, 0)
+
+	// no circuit breaker set, no-op
+	node.CircuitBreaker = nil
+	assert.NoError(t, circuitBreakerReaderBehaviour(node, hub, func(_ context.Context, _ Message) error {
+		return nil
+	})(context.Background(), Message{}))
+}
@@ -19,6 +19,13 @@ type ReaderNode struct {
 	RetryTimeout          time.Duration
 	Reader                Reader
 	MaxHandlerPoolSize    int
+	DeadLetterStream      string
+	ManualAck             bool
+	BatchSize             int
+	RateLimit             float64
+	RateBurst             int
+	MaxInFlight           int
+	CircuitBreaker        *CircuitBreaker
 }
 
 // start schedules all workers of a ReaderNode.
@@ -5,6 +5,9 @@ import "time"
 type readerNodeOptions struct {
 	readerHandler         ReaderHandler
 	readerFunc            ReaderHandleFunc
+	batchFunc             ReaderBatchHandleFunc
+	batchSize             int
+	batchLinger           time.Duration
 	group                 string
 	concurrencyLevel      int
 	retryInitialInterval  time.Duration
@@ -13,6 +16,12 @@ type readerNodeOptions struct {
 	providerConfiguration interface{}
 	driver                Reader
 	maxHandlerPoolSize    int
+	deadLetterStream      string
+	manualAck             bool
+	rateLimit             float64
+	rateBurst             int
+	maxInFlight           int
+	circuitBreaker        *CircuitBreaker
 }
 
 // ReaderNodeOption enables configuration of a ReaderNode.
@@ -46,6 +55,33 @@ func WithHandlerFunc(l ReaderHandleFunc) ReaderNodeOption {
 	return readerFuncOption{ReaderFunc: l}
 }
 
+type batchFuncOption struct {
+	BatchFunc ReaderBatchHandleFunc
+	Size      int
+	Linger    time.Duration
+}
+
+func (o batchFuncOption) apply(opts *readerNodeOptions) {
+	opts.batchFunc = o.BatchFunc
+	opts.batchSize = o.Size
+	opts.batchLinger = o.Linger
+}
+
+// WithBatchHandlerFunc sets the ReaderBatchHandleFunc of a ReaderNode, which will process up to size messages at
+// once, waiting at most linger for a batch to be filled up.
+//
+// Note: If size or linger were defined less or equal than 0, DefaultBatchSize and DefaultBatchLinger will be used.
+// ReaderNode(s) with a ReaderHandler or ReaderHandleFunc ignore this option.
+func WithBatchHandlerFunc(f ReaderBatchHandleFunc, size int, linger time.Duration) ReaderNodeOption {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if linger <= 0 {
+		linger = DefaultBatchLinger
+	}
+	return batchFuncOption{BatchFunc: f, Size: size, Linger: linger}
+}
+
 type groupOption struct {
 	ConsumerGroup string
 }
@@ -163,3 +199,90 @@ func WithMaxHandlerPoolSize(n int) ReaderNodeOption {
 	}
 	return maxHandlerPoolSizeOption{PoolSize: n}
 }
+
+type deadLetterStreamOption struct {
+	Stream string
+}
+
+func (o deadLetterStreamOption) apply(opts *readerNodeOptions) {
+	opts.deadLetterStream = o.Stream
+}
+
+// WithDeadLetterStream sets the stream where a ReaderNode will write messages which failed to be processed after
+// all retries were exhausted (aka. dead-letter queue).
+//
+// Dead-lettered messages are reported as processed and terminated through their Acknowledger (if any).
+//
+// Note: Dead-letter messages are written using the root Hub's Writer.
+func WithDeadLetterStream(stream string) ReaderNodeOption {
+	return deadLetterStreamOption{Stream: stream}
+}
+
+type manualAckOption struct{}
+
+func (o manualAckOption) apply(opts *readerNodeOptions) {
+	opts.manualAck = true
+}
+
+// WithManualAck disables automatic message settlement of a ReaderNode, so handlers MUST settle every message using
+// its Acknowledger (see AcknowledgerFromContext).
+//
+// Note: It may not be available for some providers.
+func WithManualAck() ReaderNodeOption {
+	return manualAckOption{}
+}
+
+type rateLimitOption struct {
+	Limit float64
+	Burst int
+}
+
+func (o rateLimitOption) apply(opts *readerNodeOptions) {
+	opts.rateLimit = o.Limit
+	opts.rateBurst = o.Burst
+}
+
+// WithRateLimit sets the maximum number of messages per second processed by a ReaderNode, allowing bursts of up to
+// burst messages. The limit is shared by every job of the ReaderNode, no matter the driver or concurrency level.
+//
+// Note: If rps was defined less or equal than 0, the ReaderNode will not be rate limited. If burst was defined less
+// or equal than 0, the ReaderNode will allow bursts of 1 message.
+func WithRateLimit(rps float64, burst int) ReaderNodeOption {
+	if burst <= 0 {
+		burst = 1
+	}
+	return rateLimitOption{Limit: rps, Burst: burst}
+}
+
+type maxInFlightOption struct {
+	MaxInFlight int
+}
+
+func (o maxInFlightOption) apply(opts *readerNodeOptions) {
+	opts.maxInFlight = o.MaxInFlight
+}
+
+// WithMaxInFlight sets the maximum number of messages processed by a ReaderNode at the same time (aka. bulkhead).
+// The limit is shared by every job of the ReaderNode, no matter the driver or concurrency level.
+//
+// Note: If n was defined less or equal than 0, the ReaderNode will not limit in-flight messages.
+func WithMaxInFlight(n int) ReaderNodeOption {
+	return maxInFlightOption{MaxInFlight: n}
+}
+
+type circuitBreakerOption struct {
+	Breaker *CircuitBreaker
+}
+
+func (o circuitBreakerOption) apply(opts *readerNodeOptions) {
+	opts.circuitBreaker = o.Breaker
+}
+
+// WithCircuitBreaker sets the CircuitBreaker of a ReaderNode. While the circuit is open, executions wait for it to
+// close, pausing the consumption of the ReaderNode instead of retrying messages against an unhealthy dependency.
+//
+// Messages waiting for longer than their processing timeout fail with ErrCircuitOpen, which is neither retried nor
+// written into the dead-letter stream, so drivers might redeliver them later.
+func WithCircuitBreaker(b *CircuitBreaker) ReaderNodeOption {
+	return circuitBreakerOption{Breaker: b}
+}
@@ -167,3 +167,98 @@ func TestWithMaxHandlerPoolSize(t *testing.T) {
 	item = itemInterface.(ReaderNode)
 	assert.Equal(t, 2, item.MaxHandlerPoolSize)
 }
+
+func TestWithDeadLetterStream(t *testing.T) {
+	opt := WithDeadLetterStream("foo-dlq")
+	require.Implements(t, (*ReaderNodeOption)(nil), opt)
+
+	hub := NewHub()
+	hub.ReadByStreamKey("foo", opt)
+	itemInterface, _ := hub.readerSupervisor.readerRegistry["foo"].Get(0)
+	item := itemInterface.(ReaderNode)
+	assert.Equal(t, "foo-dlq", item.DeadLetterStream)
+}
+
+func TestWithManualAck(t *testing.T) {
+	opt := WithManualAck()
+	require.Implements(t, (*ReaderNodeOption)(nil), opt)
+
+	hub := NewHub()
+	hub.ReadByStreamKey("foo", opt)
+	hub.ReadByStreamKey("bar")
+	itemInterface, _ := hub.readerSupervisor.readerRegistry["foo"].Get(0)
+	item := itemInterface.(ReaderNode)
+	assert.True(t, item.ManualAck)
+	assert.True(t, newReaderTask(&item).ManualAck)
+	itemInterface, _ = hub.readerSupervisor.readerRegistry["bar"].Get(0)
+	assert.False(t, itemInterface.(ReaderNode).ManualAck)
+}
+
+func TestWithBatchHandlerFunc(t *testing.T) {
+	var f ReaderBatchHandleFunc = func(_ context.Context, _ []Message) error {
+		return nil
+	}
+	opt := WithBatchHandlerFunc(f, 0, 0)
+	require.Implements(t, (*ReaderNodeOption)(nil), opt)
+
+	hub := NewHub()
+	hub.ReadByStreamKey("foo", opt, WithMaxHandlerPoolSize(2))
+	itemInterface, _ := hub.readerSupervisor.readerRegistry["foo"].Get(0)
+	item := itemInterface.(ReaderNode)
+	assert.NotNil(t, item.HandlerFunc)
+	assert.Equal(t, DefaultBatchSize, item.BatchSize)
+	assert.Equal(t, DefaultBatchLinger, opt.(batchFuncOption).Linger)
+	// handler pool is sized to fill up batches
+	assert.Equal(t, DefaultBatchSize, newReaderTask(&item).MaxHandlerPoolSize)
+
+	// per-message handlers take precedence
+	hub.ReadByStreamKey("bar", WithBatchHandlerFunc(f, 5, time.Second), WithHandler(ReaderHandlerNoop{}))
+	itemInterface, _ = hub.readerSupervisor.readerRegistry["bar"].Get(0)
+	assert.Zero(t, itemInterface.(ReaderNode).BatchSize)
+}
+
+func TestWithRateLimit(t *testing.T) {
+	opt := WithRateLimit(10, 0)
+	require.Implements(t, (*ReaderNodeOption)(nil), opt)
+
+	hub := NewHub()
+	hub.ReadByStreamKey("foo", opt)
+	itemInterface, _ := hub.readerSupervisor.readerRegistry["foo"].Get(0)
+	item := itemInterface.(ReaderNode)
+	assert.Equal(t, float64(10), item.RateLimit)
+	assert.Equal(t, 1, item.RateBurst)
+
+	hub.ReadByStreamKey("bar", WithRateLimit(0.5, 5))
+	itemInterface, _ = hub.readerSupervisor.readerRegistry["bar"].Get(0)
+	item = itemInterface.(ReaderNode)
+	assert.Equal(t, 0.5, item.RateLimit)
+	assert.Equal(t, 5, item.RateBurst)
+}
+
+func TestWithMaxInFlight(t *testing.T) {
+	opt := WithMaxInFlight(4)
+	require.Implements(t, (*ReaderNodeOption)(nil), opt)
+
+	hub := NewHub()
+	hub.ReadByStreamKey("foo", opt)
+	hub.ReadByStreamKey("bar")
+	itemInterface, _ := hub.readerSupervisor.readerRegistry["foo"].Get(0)
+	assert.Equal(t, 4, itemInterface.(ReaderNode).MaxInFlight)
+	itemInterface, _ = hub.readerSupervisor.readerRegistry["bar"].Get(0)
+	assert.Zero(t, itemInterface.(ReaderNode).MaxInFlight)
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker("foo")
+	opt := WithCircuitBreaker(breaker)
+	require.Implements(t, (*ReaderNodeOption)(nil), opt)
+
+	hub := NewHub()
+	hub.ReadByStreamKey("foo", opt)
+	hub.ReadByStreamKey("bar", opt)
+	itemInterface, _ := hub.readerSupervisor.readerRegistry["foo"].Get(0)
+	assert.Same(t, breaker, itemInterface.(ReaderNode).CircuitBreaker)
+	// nodes might share the same circuit breaker
+	itemInterface, _ = hub.readerSupervisor.readerRegistry["bar"].Get(0)
+	assert.Same(t, breaker, itemInterface.(ReaderNode).CircuitBreaker)
+}
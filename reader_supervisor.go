@@ -2,6 +2,8 @@ package streams
 
 import (
 	"context"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/emirpasic/gods/lists/singlylinkedlist"
@@ -24,6 +26,13 @@ type readerSupervisor struct {
 	parentHub          *Hub
 	readerRegistry     map[string]*singlylinkedlist.List
 	baseReaderNodeOpts []ReaderNodeOption
+
+	// mu guards closed and cancelFuncs. In addition, in-flight handler registrations hold a read lock so none of
+	// them is registered after shutdown started waiting for them.
+	mu          sync.RWMutex
+	closed      bool
+	cancelFuncs []context.CancelFunc
+	inFlight    sync.WaitGroup
 }
 
 func newReaderSupervisor(h *Hub) *readerSupervisor {
@@ -65,6 +74,15 @@ func (s *readerSupervisor) forkNode(stream string, opts ...ReaderNodeOption) {
 		RetryTimeout:          baseOpts.retryTimeout,
 		Reader:                baseOpts.driver,
 		MaxHandlerPoolSize:    baseOpts.maxHandlerPoolSize,
+		DeadLetterStream:      baseOpts.deadLetterStream,
+		ManualAck:             baseOpts.manualAck,
+		RateLimit:             baseOpts.rateLimit,
+		RateBurst:             baseOpts.rateBurst,
+		MaxInFlight:           baseOpts.maxInFlight,
+		CircuitBreaker:        baseOpts.circuitBreaker,
+	}
+	if baseOpts.readerHandler == nil && baseOpts.readerFunc == nil && baseOpts.batchFunc != nil {
+		node.BatchSize = baseOpts.batchSize
 	}
 	node.HandlerFunc = s.attachDefaultBehaviours(&node)
 
@@ -78,7 +96,7 @@ func (s *readerSupervisor) forkNode(stream string, opts ...ReaderNodeOption) {
 }
 
 func (s *readerSupervisor) ReaderHandleFunc(baseOpts readerNodeOptions) ReaderHandleFunc {
-	if baseOpts.readerFunc == nil && baseOpts.readerHandler == nil {
+	if baseOpts.readerFunc == nil && baseOpts.readerHandler == nil && baseOpts.batchFunc == nil {
 		return nil
 	}
 
@@ -87,6 +105,8 @@ func (s *readerSupervisor) ReaderHandleFunc(baseOpts readerNodeOptions) ReaderHa
 		handler = baseOpts.readerHandler.Read
 	} else if baseOpts.readerFunc != nil {
 		handler = baseOpts.readerFunc
+	} else if baseOpts.batchFunc != nil {
+		handler = newReaderBatcher(baseOpts.batchFunc, baseOpts.batchSize, baseOpts.batchLinger).handle
 	}
 	return handler
 }
@@ -98,11 +118,37 @@ func (s *readerSupervisor) attachDefaultBehaviours(node *ReaderNode) ReaderHandl
 	for _, b := range s.parentHub.ReaderBehaviours {
 		node.HandlerFunc = b(node, s.parentHub, node.HandlerFunc)
 	}
-	return node.HandlerFunc
+	return s.trackHandler(node.HandlerFunc)
+}
+
+// trackHandler keeps count of in-flight executions of the given handler, so they can be awaited on shutdown.
+//
+// Executions requested after shutdown started are rejected with ErrHubClosed.
+func (s *readerSupervisor) trackHandler(next ReaderHandleFunc) ReaderHandleFunc {
+	return func(ctx context.Context, message Message) error {
+		s.mu.RLock()
+		if s.closed {
+			s.mu.RUnlock()
+			return ErrHubClosed
+		}
+		s.inFlight.Add(1)
+		s.mu.RUnlock()
+		defer s.inFlight.Done()
+		return next(ctx, message)
+	}
 }
 
 // startNodes boots up all nodes from the readerSupervisor's ReaderRegistry.
+//
+// Nodes are started using a child context of the given one, which is canceled on shutdown.
 func (s *readerSupervisor) startNodes(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.cancelFuncs = append(s.cancelFuncs, cancel)
+	s.mu.Unlock()
 	for _, list := range s.readerRegistry {
 		for _, item := range list.Values() {
 			readerNode := item.(ReaderNode)
@@ -110,3 +156,68 @@ func (s *readerSupervisor) startNodes(ctx context.Context) {
 		}
 	}
 }
+
+// shutdown stops every ReaderNode gracefully.
+//
+// It rejects new handler executions, shuts down every Reader implementing Shutdowner, waits for in-flight handlers
+// to finish (bounded by the given context) and finally cancels the context of every stream-reading job.
+func (s *readerSupervisor) shutdown(ctx context.Context) MultiError {
+	s.mu.Lock()
+	s.closed = true
+	cancelFuncs := s.cancelFuncs
+	s.cancelFuncs = nil
+	s.mu.Unlock()
+	defer func() {
+		for _, cancel := range cancelFuncs {
+			cancel()
+		}
+	}()
+
+	errs := MultiError{}
+	for _, r := range s.readers() {
+		shutdowner, ok := r.(Shutdowner)
+		if !ok {
+			continue
+		}
+		if err := shutdowner.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+	return errs
+}
+
+// readers retrieves the set of unique Reader(s) used by the registered nodes.
+func (s *readerSupervisor) readers() []Reader {
+	readers := make([]Reader, 0)
+	appendUnique := func(r Reader) {
+		if r == nil {
+			return
+		}
+		if reflect.TypeOf(r).Comparable() {
+			for _, registered := range readers {
+				if reflect.TypeOf(registered).Comparable() && registered == r {
+					return
+				}
+			}
+		}
+		readers = append(readers, r)
+	}
+	appendUnique(s.parentHub.Reader)
+	for _, list := range s.readerRegistry {
+		for _, item := range list.Values() {
+			appendUnique(item.(ReaderNode).Reader)
+		}
+	}
+	return readers
+}
@@ -1,6 +1,13 @@
 package streams
 
-import "time"
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// readerNodeSeq sequence of ReaderTask node identifiers.
+var readerNodeSeq uint64
 
 // ReaderTask job metadata in order to be executed by the ListenerNodeDriver.
 type ReaderTask struct {
@@ -10,15 +17,31 @@ type ReaderTask struct {
 	Configuration      interface{}
 	Timeout            time.Duration
 	MaxHandlerPoolSize int
+	// ManualAck disables automatic message settlement; handlers settle messages using their Acknowledger.
+	ManualAck bool
+	// BatchSize number of messages processed at once by the ReaderNode's handler, 0 if messages are processed one
+	// by one. MaxHandlerPoolSize is never lower than BatchSize, so drivers may fill up batches concurrently.
+	BatchSize int
+	// NodeID identifies the ReaderNode the task was forked from. Tasks sharing a NodeID are concurrent copies of the
+	// same node (ConcurrencyLevel), so drivers deliver each message to a single one of them even if they have no Group.
+	NodeID string
 }
 
 func newReaderTask(n *ReaderNode) ReaderTask {
+	poolSize := n.MaxHandlerPoolSize
+	if poolSize < n.BatchSize {
+		// batches are filled up by concurrent handler executions
+		poolSize = n.BatchSize
+	}
 	return ReaderTask{
 		Stream:             n.Stream,
 		HandlerFunc:        n.HandlerFunc,
 		Group:              n.Group,
 		Configuration:      n.ProviderConfiguration,
 		Timeout:            n.RetryTimeout,
-		MaxHandlerPoolSize: n.MaxHandlerPoolSize,
+		MaxHandlerPoolSize: poolSize,
+		ManualAck:          n.ManualAck,
+		BatchSize:          n.BatchSize,
+		NodeID:             strconv.FormatUint(atomic.AddUint64(&readerNodeSeq, 1), 10),
 	}
 }
@@ -0,0 +1,186 @@
+package streams
+
+import (
+	"context"
+	"sync"
+)
+
+// RouteMatcher reports whether a message must be sent to the writers of a route. The given StreamMetadata is empty
+// if the RoutingWriter has no StreamRegistry or the message stream is not registered.
+type RouteMatcher func(message Message, metadata StreamMetadata) bool
+
+type writerRoute struct {
+	match RouteMatcher
+	// writers positions of the route writers in the RoutingWriter set of writers
+	writers []int
+}
+
+// RoutingWriter is a Writer sending each message to a different Writer (e.g. a driver) depending on the message
+// stream (by name or glob pattern) or its StreamMetadata. Thus, a single Hub might write some streams into Amazon SNS,
+// others into Amazon EventBridge and internal ones into the in-memory bus.
+//
+// Routes might send messages to several writers at once (tee). Batches are split per target Writer.
+type RoutingWriter struct {
+	opts routingWriterOptions
+}
+
+var (
+	_ Writer     = RoutingWriter{}
+	_ Shutdowner = RoutingWriter{}
+)
+
+// NewRoutingWriter allocates a new RoutingWriter with the given routes.
+func NewRoutingWriter(opts ...RoutingWriterOption) RoutingWriter {
+	baseOpts := routingWriterOptions{
+		routes:  make([]writerRoute, 0),
+		writers: make([]Writer, 0),
+	}
+	for _, o := range opts {
+		o.apply(&baseOpts)
+	}
+	return RoutingWriter{
+		opts: baseOpts,
+	}
+}
+
+// Write sends the given message to the writers of its route. If the route has several writers, returned error
+// contains every failure.
+//
+// Returns ErrMissingRoute if the message matches no route and there is no default route.
+func (r RoutingWriter) Write(ctx context.Context, message Message) error {
+	targets := r.route(message)
+	if len(targets) == 0 {
+		return ErrMissingRoute
+	} else if len(targets) == 1 {
+		return r.opts.writers[targets[0]].Write(ctx, message)
+	}
+
+	errs := MultiError{}
+	for _, target := range targets {
+		if err := r.opts.writers[target].Write(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// routedBatch is the set of messages from a batch sent to the same Writer.
+type routedBatch struct {
+	writer   int
+	messages []Message
+	// indexes positions of the messages in the original batch
+	indexes []int
+	res     BatchResult
+}
+
+// WriteBatch splits the given set of messages per target Writer, writing every split concurrently.
+//
+// A message sent to several writers (tee) is marked as failed if any of them failed.
+func (r RoutingWriter) WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
+	res := NewBatchResult(messages)
+	batches := make([]*routedBatch, 0)
+	batchByWriter := map[int]*routedBatch{}
+	for i, msg := range messages {
+		targets := r.route(msg)
+		if len(targets) == 0 {
+			res[i].Err = ErrMissingRoute
+			continue
+		}
+		for _, target := range targets {
+			batch, ok := batchByWriter[target]
+			if !ok {
+				batch = &routedBatch{writer: target}
+				batchByWriter[target] = batch
+				batches = append(batches, batch)
+			}
+			batch.messages = append(batch.messages, msg)
+			batch.indexes = append(batch.indexes, i)
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(batches))
+	for _, batch := range batches {
+		go func(batch *routedBatch) {
+			defer wg.Done()
+			writeRes, err := r.opts.writers[batch.writer].WriteBatch(ctx, batch.messages...)
+			batch.res = make(BatchResult, len(batch.messages))
+			positions := make([]int, len(batch.messages))
+			for i := range positions {
+				positions[i] = i
+			}
+			batch.res.merge(positions, writeRes, err)
+		}(batch)
+	}
+	wg.Wait()
+
+	errs := make([]MultiError, len(messages))
+	for _, batch := range batches {
+		for pos, i := range batch.indexes {
+			if err := batch.res[pos].Err; err != nil {
+				errs[i] = append(errs[i], err)
+			}
+		}
+	}
+	for i, msgErrs := range errs {
+		if len(msgErrs) == 1 {
+			res[i].Err = msgErrs[0]
+		} else if len(msgErrs) > 1 {
+			res[i].Err = msgErrs
+		}
+	}
+	return res, res.Err()
+}
+
+// Shutdown shuts down every target Writer implementing Shutdowner.
+func (r RoutingWriter) Shutdown(ctx context.Context) error {
+	errs := MultiError{}
+	for _, w := range r.opts.writers {
+		if shutdowner, ok := w.(Shutdowner); ok {
+			if err := shutdowner.Shutdown(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// route retrieves the positions of the writers the given message must be sent to.
+func (r RoutingWriter) route(message Message) []int {
+	var metadata StreamMetadata
+	if r.opts.registry != nil {
+		metadata, _ = r.opts.registry.GetByStreamName(message.Stream)
+	}
+
+	var targets []int
+	for _, route := range r.opts.routes {
+		if !route.match(message, metadata) {
+			continue
+		}
+		if !r.opts.matchAllRoutes {
+			return route.writers
+		}
+		targets = appendUniqueIndexes(targets, route.writers)
+	}
+	if len(targets) == 0 {
+		return r.opts.defaultRoute
+	}
+	return targets
+}
+
+// appendUniqueIndexes appends the given indexes into dst, skipping the ones already contained.
+func appendUniqueIndexes(dst []int, indexes []int) []int {
+	for _, i := range indexes {
+		found := false
+		for _, existing := range dst {
+			if existing == i {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, i)
+		}
+	}
+	return dst
+}
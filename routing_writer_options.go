@@ -0,0 +1,125 @@
+package streams
+
+import (
+	"path"
+	"reflect"
+)
+
+type routingWriterOptions struct {
+	routes         []writerRoute
+	defaultRoute   []int
+	writers        []Writer
+	registry       *StreamRegistry
+	matchAllRoutes bool
+}
+
+// RoutingWriterOption enables configuration of a RoutingWriter.
+type RoutingWriterOption interface {
+	apply(*routingWriterOptions)
+}
+
+// writerIndexes retrieves the position of each given Writer in the set of target writers, appending them if
+// required. Comparable writers registered more than once share the same position, so their batches are written
+// together.
+func (o *routingWriterOptions) writerIndexes(writers []Writer) []int {
+	indexes := make([]int, 0, len(writers))
+	for _, w := range writers {
+		indexes = appendUniqueIndexes(indexes, []int{o.writerIndex(w)})
+	}
+	return indexes
+}
+
+func (o *routingWriterOptions) writerIndex(w Writer) int {
+	if w != nil && reflect.TypeOf(w).Comparable() {
+		for i, existing := range o.writers {
+			// interfaces holding different dynamic types are never equal, so no comparison panic might occur
+			if existing == w {
+				return i
+			}
+		}
+	}
+	o.writers = append(o.writers, w)
+	return len(o.writers) - 1
+}
+
+type routeOption struct {
+	Match   RouteMatcher
+	Writers []Writer
+}
+
+func (o routeOption) apply(opts *routingWriterOptions) {
+	opts.routes = append(opts.routes, writerRoute{
+		match:   o.Match,
+		writers: opts.writerIndexes(o.Writers),
+	})
+}
+
+// WithRoute adds a route of a RoutingWriter, sending messages accepted by the given RouteMatcher to the given
+// writers. If several writers are given, every message is written to all of them (tee).
+//
+// Routes are evaluated in the same order they were added.
+func WithRoute(match RouteMatcher, w ...Writer) RoutingWriterOption {
+	return routeOption{Match: match, Writers: w}
+}
+
+// WithStreamRoute adds a route of a RoutingWriter, sending messages of the given stream to the given writers.
+func WithStreamRoute(stream string, w ...Writer) RoutingWriterOption {
+	return routeOption{
+		Match: func(message Message, _ StreamMetadata) bool {
+			return message.Stream == stream
+		},
+		Writers: w,
+	}
+}
+
+// WithStreamPatternRoute adds a route of a RoutingWriter, sending messages of every stream matching the given glob
+// pattern (e.g. `org.neutrino.*`) to the given writers. Pattern syntax is the same as path.Match; malformed patterns
+// never match.
+func WithStreamPatternRoute(pattern string, w ...Writer) RoutingWriterOption {
+	return routeOption{
+		Match: func(message Message, _ StreamMetadata) bool {
+			ok, err := path.Match(pattern, message.Stream)
+			return ok && err == nil
+		},
+		Writers: w,
+	}
+}
+
+type defaultRouteOption struct {
+	Writers []Writer
+}
+
+func (o defaultRouteOption) apply(opts *routingWriterOptions) {
+	opts.defaultRoute = opts.writerIndexes(o.Writers)
+}
+
+// WithDefaultRoute sets the writers of a RoutingWriter used for messages matching no route.
+func WithDefaultRoute(w ...Writer) RoutingWriterOption {
+	return defaultRouteOption{Writers: w}
+}
+
+type routeStreamRegistryOption struct {
+	Registry *StreamRegistry
+}
+
+func (o routeStreamRegistryOption) apply(opts *routingWriterOptions) {
+	opts.registry = o.Registry
+}
+
+// WithRouteStreamRegistry sets the StreamRegistry a RoutingWriter uses to retrieve the StreamMetadata passed to each
+// RouteMatcher (e.g. the Hub StreamRegistry). Without it, matchers get an empty StreamMetadata.
+func WithRouteStreamRegistry(r *StreamRegistry) RoutingWriterOption {
+	return routeStreamRegistryOption{Registry: r}
+}
+
+type matchAllRoutesOption struct{}
+
+func (o matchAllRoutesOption) apply(opts *routingWriterOptions) {
+	opts.matchAllRoutes = true
+}
+
+// WithMatchAllRoutes makes a RoutingWriter write each message to the writers of every matching route (fan-out)
+// instead of the first matching route only.
+func WithMatchAllRoutes() RoutingWriterOption {
+	return matchAllRoutesOption{}
+}
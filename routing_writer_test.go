@@ -0,0 +1,149 @@
+package streams_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamRecorder is a Writer recording the IDs of written messages along with the number of WriteBatch calls.
+type streamRecorder struct {
+	mu         sync.Mutex
+	ids        []string
+	batchCalls int
+	err        error
+	shutdown   bool
+}
+
+var (
+	_ streams.Writer     = &streamRecorder{}
+	_ streams.Shutdowner = &streamRecorder{}
+)
+
+func (r *streamRecorder) Write(_ context.Context, message streams.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.ids = append(r.ids, message.ID)
+	return nil
+}
+
+func (r *streamRecorder) WriteBatch(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batchCalls++
+	res := streams.NewBatchResult(messages)
+	if r.err != nil {
+		res.FailAll(r.err)
+		return res, r.err
+	}
+	for _, msg := range messages {
+		r.ids = append(r.ids, msg.ID)
+	}
+	return res, nil
+}
+
+func (r *streamRecorder) Shutdown(_ context.Context) error {
+	r.shutdown = true
+	return nil
+}
+
+func TestRoutingWriter_Write(t *testing.T) {
+	sns, eventBridge, bus, fallback := &streamRecorder{}, &streamRecorder{}, &streamRecorder{}, &streamRecorder{}
+	w := streams.NewRoutingWriter(
+		streams.WithStreamRoute("org.neutrino.foo", sns),
+		streams.WithStreamPatternRoute("org.neutrino.*", eventBridge),
+		streams.WithStreamPatternRoute("internal.*", bus, sns),
+		streams.WithStreamPatternRoute("[", bus),
+	)
+	ctx := context.Background()
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "1", Stream: "org.neutrino.foo"}))
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "2", Stream: "org.neutrino.bar"}))
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "3", Stream: "internal.baz"}))
+	assert.ErrorIs(t, w.Write(ctx, streams.Message{ID: "4", Stream: "external.baz"}), streams.ErrMissingRoute)
+
+	assert.Equal(t, []string{"1", "3"}, sns.ids)
+	assert.Equal(t, []string{"2"}, eventBridge.ids)
+	assert.Equal(t, []string{"3"}, bus.ids)
+
+	// tee failures are aggregated
+	bus.err = errors.New("generic bus error")
+	err := w.Write(ctx, streams.Message{ID: "5", Stream: "internal.baz"})
+	assert.EqualError(t, err, "generic bus error")
+	assert.Equal(t, []string{"1", "3", "5"}, sns.ids)
+
+	w = streams.NewRoutingWriter(streams.WithStreamRoute("org.neutrino.foo", sns),
+		streams.WithDefaultRoute(fallback))
+	require.NoError(t, w.Write(ctx, streams.Message{ID: "6", Stream: "external.baz"}))
+	assert.Equal(t, []string{"6"}, fallback.ids)
+}
+
+func TestRoutingWriter_WriteBatch(t *testing.T) {
+	sns, eventBridge, bus := &streamRecorder{}, &streamRecorder{}, &streamRecorder{}
+	w := streams.NewRoutingWriter(
+		streams.WithStreamPatternRoute("org.neutrino.*", sns),
+		streams.WithStreamPatternRoute("org.*", eventBridge),
+		streams.WithStreamPatternRoute("internal.*", bus, sns),
+		streams.WithMatchAllRoutes(),
+	)
+	res, err := w.WriteBatch(context.Background(),
+		streams.Message{ID: "1", Stream: "org.neutrino.foo"},
+		streams.Message{ID: "2", Stream: "org.acme.foo"},
+		streams.Message{ID: "3", Stream: "internal.foo"},
+		streams.Message{ID: "4", Stream: "external.foo"},
+		streams.Message{ID: "5", Stream: "org.neutrino.bar"})
+	assert.ErrorIs(t, err, streams.ErrMissingRoute)
+	require.Len(t, res, 5)
+	assert.Equal(t, uint32(4), res.Succeeded())
+	assert.ErrorIs(t, res[3].Err, streams.ErrMissingRoute)
+
+	// every target gets a single batch
+	assert.Equal(t, []string{"1", "3", "5"}, sns.ids)
+	assert.Equal(t, []string{"1", "2", "5"}, eventBridge.ids)
+	assert.Equal(t, []string{"3"}, bus.ids)
+	assert.Equal(t, 1, sns.batchCalls)
+	assert.Equal(t, 1, eventBridge.batchCalls)
+	assert.Equal(t, 1, bus.batchCalls)
+
+	// a message fails if any of its targets failed
+	bus.err = errors.New("generic bus error")
+	res, err = w.WriteBatch(context.Background(),
+		streams.Message{ID: "6", Stream: "internal.foo"},
+		streams.Message{ID: "7", Stream: "org.neutrino.foo"})
+	assert.Error(t, err)
+	assert.EqualError(t, res[0].Err, "generic bus error")
+	assert.NoError(t, res[1].Err)
+	assert.Equal(t, []string{"1", "3", "5", "6", "7"}, sns.ids)
+
+	require.NoError(t, w.Shutdown(context.Background()))
+	assert.True(t, sns.shutdown)
+	assert.True(t, eventBridge.shutdown)
+	assert.True(t, bus.shutdown)
+}
+
+func TestRoutingWriter_Metadata(t *testing.T) {
+	hub := streams.NewHub()
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream:        "foo-stream",
+		StreamVersion: 2,
+	})
+	legacy, current := &streamRecorder{}, &streamRecorder{}
+	hub.Writer = streams.NewRoutingWriter(
+		streams.WithRouteStreamRegistry(&hub.StreamRegistry),
+		streams.WithRoute(func(_ streams.Message, metadata streams.StreamMetadata) bool {
+			return metadata.StreamVersion >= 2
+		}, current),
+		streams.WithDefaultRoute(legacy),
+	)
+	require.NoError(t, hub.Write(context.Background(), fooMessage{Foo: "foo"}))
+	require.NoError(t, hub.WriteRawMessage(context.Background(), streams.Message{ID: "1", Stream: "bar-stream"}))
+	assert.Len(t, current.ids, 1)
+	assert.Equal(t, []string{"1"}, legacy.ids)
+}
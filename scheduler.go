@@ -0,0 +1,68 @@
+package streams
+
+import (
+	"context"
+	"time"
+)
+
+// Scheduler durably stores messages with a delivery time (Message.DeliverAt), writing them once the time is reached.
+//
+// Schedulers handle delays beyond driver limits (e.g. 15 minutes on Amazon SQS) or the lifetime of the program
+// (e.g. reminders and timeouts of long-running workflows).
+type Scheduler interface {
+	// Schedule stores the given message until its delivery time.
+	Schedule(ctx context.Context, message Message) error
+}
+
+// NewSchedulerWriterBehaviour creates a WriterBehaviour handing messages with a delivery time further than the given
+// maximum delay over to the given Scheduler; the rest of the messages are written by the next Writer.
+//
+// The maximum delay SHOULD be the longest delay supported by the underlying driver (zero if it has no delayed
+// delivery support).
+func NewSchedulerWriterBehaviour(s Scheduler, maxDelay time.Duration) WriterBehaviour {
+	return func(_ *Hub, next Writer) Writer {
+		return schedulingWriter{
+			next:      next,
+			scheduler: s,
+			maxDelay:  maxDelay,
+		}
+	}
+}
+
+type schedulingWriter struct {
+	next      Writer
+	scheduler Scheduler
+	maxDelay  time.Duration
+}
+
+var _ Writer = schedulingWriter{}
+
+func (w schedulingWriter) Write(ctx context.Context, message Message) error {
+	if w.mustSchedule(message) {
+		return w.scheduler.Schedule(ctx, message)
+	}
+	return w.next.Write(ctx, message)
+}
+
+func (w schedulingWriter) WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
+	res := NewBatchResult(messages)
+	immediate := make([]Message, 0, len(messages))
+	indexes := make([]int, 0, len(messages))
+	for i, msg := range messages {
+		if w.mustSchedule(msg) {
+			res[i].Err = w.scheduler.Schedule(ctx, msg)
+			continue
+		}
+		immediate = append(immediate, msg)
+		indexes = append(indexes, i)
+	}
+	if len(immediate) > 0 {
+		nextRes, err := w.next.WriteBatch(ctx, immediate...)
+		res.merge(indexes, nextRes, err)
+	}
+	return res, res.Err()
+}
+
+func (w schedulingWriter) mustSchedule(message Message) bool {
+	return message.Delay() > w.maxDelay
+}
@@ -0,0 +1,63 @@
+package streams_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schedulerFunc func(context.Context, streams.Message) error
+
+func (f schedulerFunc) Schedule(ctx context.Context, message streams.Message) error {
+	return f(ctx, message)
+}
+
+func TestNewSchedulerWriterBehaviour(t *testing.T) {
+	var scheduled, written []string
+	errScheduler := errors.New("generic scheduler error")
+	hub := streams.NewHub(streams.WithWriterBehaviours(streams.NewSchedulerWriterBehaviour(
+		schedulerFunc(func(_ context.Context, message streams.Message) error {
+			if message.Subject == "fail" {
+				return errScheduler
+			}
+			scheduled = append(scheduled, message.ID)
+			return nil
+		}), time.Minute*15)))
+	hub.Writer = writerNoopHook{
+		onWrite: func(_ context.Context, message streams.Message) error {
+			written = append(written, message.ID)
+			return nil
+		},
+		onWriteBatch: func(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+			for _, msg := range messages {
+				written = append(written, msg.ID)
+			}
+			return streams.NewBatchResult(messages), nil
+		},
+	}
+	hub.RegisterStream(fooMessage{}, streams.StreamMetadata{
+		Stream: "foo-stream",
+	})
+	ctx := context.Background()
+	require.NoError(t, hub.Write(ctx, fooMessage{Foo: "foo"}))
+	require.NoError(t, hub.Write(ctx, fooMessage{Foo: "foo"}, streams.WithDelay(time.Minute)))
+	require.NoError(t, hub.Write(ctx, fooMessage{Foo: "foo"}, streams.WithDeliverAt(time.Now().Add(time.Hour))))
+	assert.Len(t, written, 2)
+	assert.Len(t, scheduled, 1)
+
+	deliverAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	res, err := hub.WriteRawMessageBatch(ctx,
+		streams.Message{ID: "1"},
+		streams.Message{ID: "2", DeliverAt: deliverAt},
+		streams.Message{ID: "3", DeliverAt: deliverAt, Subject: "fail"})
+	assert.ErrorIs(t, err, errScheduler)
+	assert.Equal(t, uint32(2), res.Succeeded())
+	assert.ErrorIs(t, res[2].Err, errScheduler)
+	assert.Equal(t, "1", written[2])
+	assert.Equal(t, "2", scheduled[1])
+}
@@ -0,0 +1,14 @@
+package streams
+
+import "context"
+
+// Shutdowner is a component (e.g. Reader or Writer driver) holding resources which MUST be released gracefully
+// when the Hub is shutting down.
+//
+// Reader implementations SHOULD stop fetching new messages and wait for running handlers to finish, while Writer
+// implementations SHOULD flush any buffered message.
+type Shutdowner interface {
+	// Shutdown releases the component resources gracefully. Implementations MUST return once the given context is
+	// done, even if the process has not finished yet.
+	Shutdown(ctx context.Context) error
+}
@@ -22,6 +22,11 @@ type StreamMetadata struct {
 	SchemaDefinitionName string
 	SchemaVersion        int
 	GoType               reflect2.Type
+	// PartitionKey default ordering key of the stream messages. Useful when total ordering of a stream is desired
+	// (e.g. single message group of an Amazon SQS FIFO queue).
+	PartitionKey string
+	// DeduplicateByID uses the message ID as deduplication identifier if none was set.
+	DeduplicateByID bool
 }
 
 // StreamRegistry is an in-memory storage of streams metadata used by Hub and any external agent to set and
@@ -0,0 +1,108 @@
+package streams
+
+import "time"
+
+type writeOptions struct {
+	headers         map[string]string
+	partitionKey    string
+	deduplicationID string
+	deliverAt       time.Time
+	delay           time.Duration
+}
+
+// WriteOption enables configuration of a single Hub write operation.
+type WriteOption interface {
+	apply(*writeOptions)
+}
+
+func newWriteOptions(opts []WriteOption) writeOptions {
+	baseOpts := writeOptions{}
+	for _, o := range opts {
+		o.apply(&baseOpts)
+	}
+	return baseOpts
+}
+
+type headersOption struct {
+	Headers map[string]string
+}
+
+func (o headersOption) apply(opts *writeOptions) {
+	if opts.headers == nil {
+		opts.headers = make(map[string]string, len(o.Headers))
+	}
+	for k, v := range o.Headers {
+		opts.headers[k] = v
+	}
+}
+
+// WithHeaders sets extension attributes (CloudEvents extensions) of the message to be written.
+//
+// Headers set with this option override headers with the same key set through the context (ContextWithHeaders).
+func WithHeaders(h map[string]string) WriteOption {
+	return headersOption{Headers: h}
+}
+
+// WithHeader sets an extension attribute (CloudEvents extension) of the message to be written.
+func WithHeader(key, value string) WriteOption {
+	return headersOption{Headers: map[string]string{key: value}}
+}
+
+type partitionKeyOption struct {
+	Key string
+}
+
+func (o partitionKeyOption) apply(opts *writeOptions) {
+	opts.partitionKey = o.Key
+}
+
+// WithPartitionKey sets the ordering key of the message to be written.
+//
+// Overrides the key set by either the StreamMetadata or the Partitioned interface.
+func WithPartitionKey(key string) WriteOption {
+	return partitionKeyOption{Key: key}
+}
+
+type deduplicationIDOption struct {
+	ID string
+}
+
+func (o deduplicationIDOption) apply(opts *writeOptions) {
+	opts.deduplicationID = o.ID
+}
+
+// WithDeduplicationID sets the deduplication identifier of the message to be written.
+//
+// Overrides the identifier set by either the StreamMetadata or the Deduplicated interface.
+func WithDeduplicationID(id string) WriteOption {
+	return deduplicationIDOption{ID: id}
+}
+
+type deliverAtOption struct {
+	Time time.Time
+}
+
+func (o deliverAtOption) apply(opts *writeOptions) {
+	opts.deliverAt = o.Time
+	opts.delay = 0
+}
+
+// WithDeliverAt sets the time before which the message to be written MUST NOT be delivered to readers (e.g.
+// reminders or timeouts).
+func WithDeliverAt(t time.Time) WriteOption {
+	return deliverAtOption{Time: t}
+}
+
+type delayOption struct {
+	Delay time.Duration
+}
+
+func (o delayOption) apply(opts *writeOptions) {
+	opts.delay = o.Delay
+	opts.deliverAt = time.Time{}
+}
+
+// WithDelay delays the delivery of the message to be written by the given duration, starting from the write time.
+func WithDelay(d time.Duration) WriteOption {
+	return delayOption{Delay: d}
+}
@@ -15,10 +15,9 @@ type Writer interface {
 	// WriteBatch inserts a set of messages into a stream assigned to the message in the StreamRegistry in order to propagate the
 	// data to a set of subscribed systems for further processing.
 	//
-	// Depending on the underlying Writer driver implementation, this function MIGHT return an error if a single operation failed,
-	// or it MIGHT return an error if the whole operation failed. In addition, this function will return the number of
-	// successful messages written into streams.
-	WriteBatch(ctx context.Context, messages ...Message) (uint32, error)
+	// Returns the result of each message in the same order messages were given, along with an error if any message
+	// failed (result's Err).
+	WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error)
 }
 
 type noopWriter struct{}
@@ -32,6 +31,6 @@ func (n noopWriter) Write(_ context.Context, _ Message) error {
 }
 
 // WriteBatch is the no-op implementation of Writer.WriteBatch()
-func (n noopWriter) WriteBatch(_ context.Context, _ ...Message) (uint32, error) {
-	return 0, nil
+func (n noopWriter) WriteBatch(_ context.Context, messages ...Message) (BatchResult, error) {
+	return NewBatchResult(messages), nil
 }
@@ -0,0 +1,66 @@
+package streams
+
+import "context"
+
+// WriterBehaviour is a middleware function with extra functionality which will be executed prior the Writer
+// component of a Hub for every outgoing message (e.g. logging, metrics, header injection, validation, retries or
+// redaction).
+//
+// The middleware gets injected the root Hub instance and the parent Writer. Thus, it MUST call the parent Writer
+// to continue with the write process.
+type WriterBehaviour func(hub *Hub, next Writer) Writer
+
+// WriterInterceptorFunc intercepts an outgoing message, retrieving the message to be written (e.g. with extra headers
+// or redacted data).
+//
+// Returns an error to abort the write process.
+type WriterInterceptorFunc func(ctx context.Context, message Message) (Message, error)
+
+// NewWriterInterceptorBehaviour creates a WriterBehaviour executing the given interceptor for every message from
+// both single and batch writes.
+//
+// Messages failing to be intercepted are marked as failed on the batch result; the rest of the batch is written.
+func NewWriterInterceptorBehaviour(f WriterInterceptorFunc) WriterBehaviour {
+	return func(_ *Hub, next Writer) Writer {
+		return writerInterceptor{
+			next:      next,
+			intercept: f,
+		}
+	}
+}
+
+type writerInterceptor struct {
+	next      Writer
+	intercept WriterInterceptorFunc
+}
+
+var _ Writer = writerInterceptor{}
+
+func (w writerInterceptor) Write(ctx context.Context, message Message) error {
+	message, err := w.intercept(ctx, message)
+	if err != nil {
+		return err
+	}
+	return w.next.Write(ctx, message)
+}
+
+func (w writerInterceptor) WriteBatch(ctx context.Context, messages ...Message) (BatchResult, error) {
+	res := NewBatchResult(messages)
+	intercepted := make([]Message, 0, len(messages))
+	indexes := make([]int, 0, len(messages))
+	for i, msg := range messages {
+		msg, err := w.intercept(ctx, msg)
+		if err != nil {
+			res[i].Err = err
+			continue
+		}
+		intercepted = append(intercepted, msg)
+		indexes = append(indexes, i)
+	}
+	if len(intercepted) > 0 {
+		// writers might return a nil result for whole-batch failures
+		nextRes, err := w.next.WriteBatch(ctx, intercepted...)
+		res.merge(indexes, nextRes, err)
+	}
+	return res, res.Err()
+}
@@ -0,0 +1,136 @@
+package streams_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/neutrinocorp/streams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracingWriterBehaviour appends its name into calls before calling the parent Writer.
+func tracingWriterBehaviour(name string, calls *[]string) streams.WriterBehaviour {
+	return func(_ *streams.Hub, next streams.Writer) streams.Writer {
+		return writerNoopHook{
+			onWrite: func(ctx context.Context, message streams.Message) error {
+				*calls = append(*calls, name)
+				return next.Write(ctx, message)
+			},
+			onWriteBatch: func(ctx context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+				*calls = append(*calls, name)
+				return next.WriteBatch(ctx, messages...)
+			},
+		}
+	}
+}
+
+func TestHub_WriterBehaviours(t *testing.T) {
+	var calls []string
+	hub := streams.NewHub(streams.WithWriterBehaviours(
+		tracingWriterBehaviour("first", &calls),
+		tracingWriterBehaviour("second", &calls)))
+	hub.Writer = writerNoopHook{
+		onWrite: func(_ context.Context, _ streams.Message) error {
+			calls = append(calls, "writer")
+			return nil
+		},
+		onWriteBatch: func(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+			calls = append(calls, "writer")
+			return streams.NewBatchResult(messages), nil
+		},
+	}
+
+	require.NoError(t, hub.WriteRawMessage(context.Background(), streams.Message{}))
+	// behaviours are executed in descending order
+	assert.Equal(t, []string{"second", "first", "writer"}, calls)
+
+	calls = nil
+	res, err := hub.WriteRawMessageBatch(context.Background(), streams.Message{}, streams.Message{})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), res.Succeeded())
+	assert.Equal(t, []string{"second", "first", "writer"}, calls)
+}
+
+func TestHub_WriterBehaviours_BuiltOnce(t *testing.T) {
+	var built, written int
+	hub := streams.NewHub(streams.WithWriterBehaviours(func(_ *streams.Hub, next streams.Writer) streams.Writer {
+		built++
+		return writerNoopHook{
+			onWrite: func(ctx context.Context, message streams.Message) error {
+				written++
+				return next.Write(ctx, message)
+			},
+			onWriteBatch: next.WriteBatch,
+		}
+	}))
+	ctx := context.Background()
+
+	// the chain is built once, so stateful behaviours keep their state across writes
+	for i := 0; i < 3; i++ {
+		require.NoError(t, hub.WriteRawMessage(ctx, streams.Message{ID: strconv.Itoa(i), Stream: "bar-stream"}))
+	}
+	_, err := hub.WriteRawMessageBatch(ctx, streams.Message{ID: "3", Stream: "bar-stream"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, built)
+	assert.Equal(t, 3, written)
+
+	// the Hub Writer might still be replaced afterwards
+	replaced := false
+	hub.Writer = writerNoopHook{
+		onWrite: func(_ context.Context, _ streams.Message) error {
+			replaced = true
+			return nil
+		},
+	}
+	require.NoError(t, hub.WriteRawMessage(ctx, streams.Message{ID: "4", Stream: "bar-stream"}))
+	assert.True(t, replaced)
+	assert.Equal(t, 1, built)
+}
+
+func TestNewWriterInterceptorBehaviour(t *testing.T) {
+	errInvalid := errors.New("invalid message")
+	var written []streams.Message
+	hub := streams.NewHub(streams.WithWriterBehaviours(
+		streams.NewWriterInterceptorBehaviour(func(_ context.Context, message streams.Message) (streams.Message,
+			error) {
+			if message.Stream == "" {
+				return message, errInvalid
+			}
+			message.Headers = map[string]string{"tenantid": "foo"}
+			return message, nil
+		})))
+	hub.Writer = writerNoopHook{
+		onWrite: func(_ context.Context, message streams.Message) error {
+			written = append(written, message)
+			return nil
+		},
+		onWriteBatch: func(_ context.Context, messages ...streams.Message) (streams.BatchResult, error) {
+			written = append(written, messages...)
+			return streams.NewBatchResult(messages), nil
+		},
+	}
+
+	assert.ErrorIs(t, hub.WriteRawMessage(context.Background(), streams.Message{}), errInvalid)
+	require.NoError(t, hub.WriteRawMessage(context.Background(), streams.Message{Stream: "foo-stream"}))
+	assert.Equal(t, map[string]string{"tenantid": "foo"}, written[0].Headers)
+
+	// failing messages are reported while the rest of the batch is written
+	res, err := hub.WriteRawMessageBatch(context.Background(), streams.Message{Stream: "foo-stream"},
+		streams.Message{})
+	assert.ErrorIs(t, err, errInvalid)
+	require.Len(t, res, 2)
+	assert.NoError(t, res[0].Err)
+	assert.ErrorIs(t, res[1].Err, errInvalid)
+	assert.Len(t, written, 2)
+
+	res, err = hub.WriteRawMessageBatch(context.Background(), streams.Message{Stream: "foo-stream"},
+		streams.Message{Stream: "bar-stream"})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), res.Succeeded())
+	assert.Len(t, written, 4)
+	assert.Equal(t, "foo", written[3].Headers["tenantid"])
+	assert.Equal(t, "foo", res[1].Message.Headers["tenantid"])
+}